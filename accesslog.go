@@ -0,0 +1,261 @@
+package xiawuyue
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessLogConfig configures the AccessLog middleware
+type AccessLogConfig struct {
+	// Format 日志格式 common / combined / json，默认 combined
+	// common 和 combined 是 Apache 的标准格式，末尾追加耗时(ms)和 request id，没有 request id 时为 -
+	Format string
+	// Output 访问日志单独输出，和 xlog 的应用日志分开，默认 stdout
+	// 可以使用 xlog.RotatingWriter / xlog.AsyncWriter
+	Output io.Writer
+	// SkipPaths 不记录的路径，eg. /healthz
+	SkipPaths []string
+	// SampleRate 采样比例 (0, 1]，0 表示全部记录，5xx 的请求总是记录
+	SampleRate float64
+}
+
+// accessLogEntry json 格式的字段
+type accessLogEntry struct {
+	Time      string  `json:"time"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Query     string  `json:"query,omitempty"`
+	Proto     string  `json:"proto"`
+	Status    int     `json:"status"`
+	Bytes     int64   `json:"bytes"`
+	LatencyMs float64 `json:"latency_ms"`
+	ClientIP  string  `json:"client_ip"`
+	UserAgent string  `json:"user_agent"`
+	Referer   string  `json:"referer,omitempty"`
+	RequestID string  `json:"request_id,omitempty"`
+}
+
+// AccessLog logs every request in the Apache common/combined format or json
+func AccessLog(conf AccessLogConfig) HandlerFunc {
+	out := conf.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	format := strings.ToLower(conf.Format)
+	if format == "" {
+		format = "combined"
+	}
+	skip := make(map[string]bool, len(conf.SkipPaths))
+	for _, p := range conf.SkipPaths {
+		skip[p] = true
+	}
+	var mu sync.Mutex
+
+	return func(c *Context) {
+		if skip[c.Req.URL.Path] {
+			c.NextHandle()
+			return
+		}
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: c.Writer}
+		c.Writer = rw
+		defer func() {
+			c.Writer = rw.ResponseWriter
+		}()
+
+		c.NextHandle()
+
+		status := rw.Status()
+		if conf.SampleRate > 0 && conf.SampleRate < 1 && status < 500 && rand.Float64() >= conf.SampleRate {
+			return
+		}
+		var line []byte
+		switch format {
+		case "json":
+			line = accessLogJSON(c, rw, start)
+		case "common":
+			line = []byte(fmt.Sprintf("%s %s\n", accessLogCommon(c, rw, start), accessLogTrailer(c, start)))
+		default:
+			line = []byte(fmt.Sprintf("%s %q %q %s\n", accessLogCommon(c, rw, start), c.Req.Referer(), c.Req.UserAgent(),
+				accessLogTrailer(c, start)))
+		}
+		mu.Lock()
+		out.Write(line)
+		mu.Unlock()
+	}
+}
+
+// accessLogCommon host ident authuser [date] "request" status bytes
+func accessLogCommon(c *Context, rw *responseWriter, start time.Time) string {
+	user := "-"
+	if c.Req.URL.User != nil && c.Req.URL.User.Username() != "" {
+		user = c.Req.URL.User.Username()
+	} else if name, _, ok := c.Req.BasicAuth(); ok && name != "" {
+		user = name
+	}
+	size := "-"
+	if rw.Size() > 0 {
+		size = fmt.Sprint(rw.Size())
+	}
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+		c.ClientIP(), user, start.Format("02/Jan/2006:15:04:05 -0700"),
+		c.Req.Method, c.Req.RequestURI, c.Req.Proto, rw.Status(), size)
+}
+
+// accessLogTrailer latency_ms request_id
+func accessLogTrailer(c *Context, start time.Time) string {
+	requestID := c.RequestID()
+	if requestID == "" {
+		requestID = "-"
+	}
+	return fmt.Sprintf("%.3f %s", float64(time.Since(start).Microseconds())/1000, requestID)
+}
+
+func accessLogJSON(c *Context, rw *responseWriter, start time.Time) []byte {
+	entry := accessLogEntry{
+		Time:      start.Format(time.RFC3339Nano),
+		Method:    c.Req.Method,
+		Path:      c.Req.URL.Path,
+		Query:     c.Req.URL.RawQuery,
+		Proto:     c.Req.Proto,
+		Status:    rw.Status(),
+		Bytes:     rw.Size(),
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Req.UserAgent(),
+		Referer:   c.Req.Referer(),
+		RequestID: c.RequestID(),
+	}
+	data, _ := json.Marshal(entry)
+	return append(data, '\n')
+}
+
+// SetTrustedProxies sets the proxies whose X-Forwarded-For and X-Real-IP headers are trusted by
+// Context.ClientIP, eg. "10.0.0.0/8" or "127.0.0.1", nothing is trusted by default
+func (x *Xia) SetTrustedProxies(proxies []string) error {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return fmt.Errorf("invalid trusted proxy %s: %w", proxy, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %s: %w", proxy, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	x.trustedProxies = prefixes
+	return nil
+}
+
+func (x *Xia) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range x.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the ip of the client, X-Forwarded-For and X-Real-IP are only checked when
+// the peer is a trusted proxy set by Xia.SetTrustedProxies, otherwise the ip of RemoteAddr is returned
+func (c *Context) ClientIP() string {
+	peer, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr))
+	if err != nil {
+		peer = c.Req.RemoteAddr
+	}
+	if c.xia == nil || !c.xia.isTrustedProxy(peer) {
+		return peer
+	}
+	if forwarded := c.Req.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		// 从右往左跳过可信的代理  第一个不可信的就是客户端
+		ips := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if ip == "" {
+				continue
+			}
+			if i == 0 || !c.xia.isTrustedProxy(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := strings.TrimSpace(c.Req.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return peer
+}
+
+// responseWriter 记录返回的状态码和字节数
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.size += int64(n)
+	return n, err
+}
+
+// Status returns the status code, 200 if nothing was written
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns the bytes of the body written
+func (w *responseWriter) Size() int64 {
+	return w.size
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response writer does not support hijack")
+}
+
+// Unwrap is used by http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
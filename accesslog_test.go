@@ -0,0 +1,117 @@
+package xiawuyue
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	x := New()
+	if err := x.SetTrustedProxies([]string{"192.0.2.0/24", "10.0.0.0/8"}); err != nil {
+		t.Fatal(err)
+	}
+	x.Use(AccessLog(AccessLogConfig{Format: "json", Output: buf, SkipPaths: []string{"/healthz"}}))
+	x.GET("/user/:id", func(c *Context) {
+		c.String(http.StatusCreated, "user %s", c.Param("id"))
+	})
+	x.GET("/healthz", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/user/7?tab=a", nil)
+	req.Header.Set("User-Agent", "xia-test")
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+	x.ServeHTTP(httptest.NewRecorder(), req)
+	x.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	var entry accessLogEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Method != "GET" || entry.Path != "/user/7" || entry.Query != "tab=a" || entry.Status != http.StatusCreated ||
+		entry.Bytes != 6 || entry.ClientIP != "10.0.0.1" || entry.UserAgent != "xia-test" || entry.RequestID != "req-1" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	buf := &bytes.Buffer{}
+	x := New()
+	x.Use(AccessLog(AccessLogConfig{Output: buf}))
+	x.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.168.1.2:5555"
+	req.Header.Set("User-Agent", "curl/8")
+	x.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	if !strings.HasPrefix(line, "192.168.1.2 - - [") || !strings.Contains(line, "\"GET / HTTP/1.1\" 200 5 \"\" \"curl/8\" ") ||
+		!strings.HasSuffix(line, " -\n") {
+		t.Errorf("got %q", line)
+	}
+
+	buf.Reset()
+	x = New()
+	x.Use(RequestID(), AccessLog(AccessLogConfig{Format: "common", Output: buf}))
+	x.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-2")
+	x.ServeHTTP(httptest.NewRecorder(), req)
+	fields := strings.Fields(buf.String())
+	if len(fields) < 2 || fields[len(fields)-1] != "req-2" || !strings.Contains(fields[len(fields)-2], ".") {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	x := New()
+	if err := x.SetTrustedProxies([]string{"10.0.0.0/8", "127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := x.SetTrustedProxies([]string{"bad"}); err == nil {
+		t.Error("invalid proxy accepted")
+	}
+
+	cases := []struct {
+		remote    string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{"203.0.113.9:1234", "1.2.3.4", "5.6.7.8", "203.0.113.9"},
+		{"127.0.0.1:1234", "1.2.3.4", "", "1.2.3.4"},
+		{"127.0.0.1:1234", "6.6.6.6, 1.2.3.4, 10.0.0.2", "", "1.2.3.4"},
+		{"10.1.1.1:1234", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		{"10.1.1.1:1234", "", "5.6.7.8", "5.6.7.8"},
+		{"10.1.1.1:1234", "", "", "10.1.1.1"},
+	}
+	for _, cs := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = cs.remote
+		if cs.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", cs.forwarded)
+		}
+		if cs.realIP != "" {
+			req.Header.Set("X-Real-IP", cs.realIP)
+		}
+		c := newContext(httptest.NewRecorder(), req)
+		c.xia = x
+		if got := c.ClientIP(); got != cs.want {
+			t.Errorf("%s %q %q got %s, want %s", cs.remote, cs.forwarded, cs.realIP, got, cs.want)
+		}
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
 	"reflect"
 	"strconv"
+
+	"github.com/ameamezhou/xiawuyue/xi18n"
+	"github.com/ameamezhou/xiawuyue/xlog"
 )
 
 // 定义一种 value 别名来格式化 request.Form 的内容 进行处理写入我们定义的结构体
@@ -26,10 +30,22 @@ type Context struct {
 	Params  map[string]string
 	// response info
 	StatusCode int
+	// RegisterHandlers 注册的路由会带上 Handler.CmdID  方便日志和监控使用
+	CmdID int
 
 	// middlewares 中间件控制
 	middlewares []HandlerFunc
 	index       int
+
+	xia   *Xia
+	route *Route           // 匹配到的路由  没有匹配时为 nil
+	funcs template.FuncMap // Render 时覆盖的模版函数
+	// i18n 由 I18n 中间件设置
+	i18n   *xi18n.Bundle
+	locale string
+	// requestID 由 RequestID 中间件设置  logger 由 Logger 创建
+	requestID string
+	logger    *xlog.Logger
 }
 
 func newContext(w http.ResponseWriter, r *http.Request) *Context {
@@ -50,6 +66,21 @@ func (c *Context) NextHandle() {
 	}
 }
 
+// Abort stops the rest of the middlewares and the handler
+func (c *Context) Abort() {
+	c.index = len(c.middlewares)
+}
+
+// AbortWithError stops the chain and responds the error through the xia error handler
+func (c *Context) AbortWithError(code int, err error) {
+	c.Abort()
+	if c.xia != nil && c.xia.errorHandler != nil {
+		c.xia.errorHandler(c, code, err)
+		return
+	}
+	defaultErrorHandler(c, code, err)
+}
+
 func (c *Context) Param(key string) string {
 	value, _ := c.Params[key]
 	return value
@@ -106,8 +137,61 @@ func (c *Context) HTML(code int, html string) {
 	c.Writer.Write([]byte(html))
 }
 
-func (c *Context) WriteTpl(t *BuildTemplate, filename string, data ResponseXia) {
-	t.WriterBuffer(c.Writer, filename, data)
+// WriteTpl 出错时和 Render 一样  DevMode 写错误页，否则交给 AbortWithError
+func (c *Context) WriteTpl(t *BuildTemplate, filename string, data ResponseXia) error {
+	err := t.WriterBuffer(c.Writer, filename, data)
+	if err == nil {
+		c.StatusCode = http.StatusOK
+		return nil
+	}
+	c.Logger().Errorf("render template %s error: %v", filename, err)
+	if t.DevMode {
+		c.Abort()
+		c.StatusCode = http.StatusInternalServerError
+		return err
+	}
+	c.AbortWithError(http.StatusInternalServerError, err)
+	return err
+}
+
+// SetFuncs overrides template functions for this request only,
+// the functions must already be declared in the BuildTemplate.FuncMap
+func (c *Context) SetFuncs(funcs template.FuncMap) {
+	if c.funcs == nil {
+		c.funcs = make(template.FuncMap)
+	}
+	for name, fn := range funcs {
+		c.funcs[name] = fn
+	}
+}
+
+// Render renders the template bound by Xia.SetTemplate with code,
+// errors are responded through the error handler and returned
+func (c *Context) Render(code int, name string, data interface{}) error {
+	if c.xia == nil || c.xia.template == nil {
+		err := errors.New("template is not set, use Xia.SetTemplate first")
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return err
+	}
+	t := c.xia.template
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	funcs, variant := c.templateFuncs(t)
+	if err := t.executeVariant(buffer, name, data, funcs, variant); err != nil {
+		c.Logger().Errorf("render template %s error: %v", name, err)
+		if t.DevMode {
+			c.Abort()
+			c.StatusCode = http.StatusInternalServerError
+			writeDebugPage(c.Writer, name, err)
+			return err
+		}
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return err
+	}
+	c.SetHeader("Content-Type", "text/html; charset=utf-8")
+	c.Status(code)
+	_, err := c.Writer.Write(buffer.Bytes())
+	return err
 }
 
 // 将form写入 struct
@@ -0,0 +1,69 @@
+package xiawuyue
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextRender(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{
+		"hello.html": `hello {{.Name}} {{user}}`,
+	})
+	x := New()
+	x.SetTemplate(&BuildTemplate{
+		BaseDir:     dir,
+		EnableCache: true,
+		FuncMap:     template.FuncMap{"user": func() string { return "nobody" }},
+	})
+	var renderErr error
+	x.GET("/hello", func(c *Context) {
+		renderErr = c.Render(http.StatusCreated, "hello.html", map[string]string{"Name": "xia"})
+	})
+	x.GET("/me", func(c *Context) {
+		c.SetFuncs(template.FuncMap{"user": func() string { return c.Query("u") }})
+		renderErr = c.Render(http.StatusOK, "hello.html", map[string]string{"Name": "xia"})
+	})
+	x.GET("/missing", func(c *Context) {
+		renderErr = c.Render(http.StatusOK, "missing.html", nil)
+	})
+	x.GET("/tpl", func(c *Context) {
+		renderErr = c.WriteTpl(x.template, "missing.html", ResponseXia{})
+	})
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/hello", http.StatusCreated, "hello xia nobody"},
+		{"/me?u=zhou", http.StatusOK, "hello xia zhou"},
+		{"/me?u=wu", http.StatusOK, "hello xia wu"},
+		{"/hello", http.StatusCreated, "hello xia nobody"},
+	}
+	for _, cs := range cases {
+		w := httptest.NewRecorder()
+		x.ServeHTTP(w, httptest.NewRequest(http.MethodGet, cs.path, nil))
+		if renderErr != nil {
+			t.Fatal(renderErr)
+		}
+		if w.Code != cs.code || w.Body.String() != cs.body {
+			t.Errorf("%s got %d %q, want %d %q", cs.path, w.Code, w.Body.String(), cs.code, cs.body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	x.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if renderErr == nil || w.Code != http.StatusInternalServerError {
+		t.Errorf("missing template got %d, err %v", w.Code, renderErr)
+	}
+
+	renderErr = nil
+	w = httptest.NewRecorder()
+	x.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tpl", nil))
+	if renderErr == nil || w.Code != http.StatusInternalServerError {
+		t.Errorf("WriteTpl missing template got %d, err %v", w.Code, renderErr)
+	}
+}
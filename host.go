@@ -0,0 +1,82 @@
+package xiawuyue
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Host creates a RouterGroup whose routes only match the given Host header,
+// the pattern can capture labels, eg. ":tenant.example.com",
+// the captured params are merged into Context.Params
+func (x *Xia) Host(pattern string) *RouterGroup {
+	newGroup := &RouterGroup{
+		host:   strings.ToLower(pattern),
+		parent: x.RouterGroup,
+		xia:    x,
+	}
+	x.groups = append(x.groups, newGroup)
+	return newGroup
+}
+
+func hostRootKey(method, host string) string {
+	if host == "" {
+		return method
+	}
+	return method + "@" + host
+}
+
+func (r *router) hasHost(host string) bool {
+	for _, h := range r.hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// addHost 记录 host pattern  精确的 host 排在带 :name 的 pattern 前面先匹配，
+// 同一类按注册顺序
+func (r *router) addHost(host string) {
+	r.hosts = append(r.hosts, host)
+	sort.SliceStable(r.hosts, func(i, j int) bool {
+		return !isHostPattern(r.hosts[i]) && isHostPattern(r.hosts[j])
+	})
+}
+
+func isHostPattern(host string) bool {
+	return strings.HasPrefix(host, ":") || strings.Contains(host, ".:")
+}
+
+// requestHost returns the lower case host without port
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// matchHost matches host with pattern label by label, ":name" captures a label
+func matchHost(pattern, host string) (map[string]string, bool) {
+	patternParts := strings.Split(pattern, ".")
+	hostParts := strings.Split(host, ".")
+	if len(patternParts) != len(hostParts) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, part := range patternParts {
+		if len(part) > 0 && part[0] == ':' {
+			if hostParts[i] == "" {
+				return nil, false
+			}
+			params[part[1:]] = hostParts[i]
+			continue
+		}
+		if part != hostParts[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
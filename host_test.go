@@ -0,0 +1,51 @@
+package xiawuyue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostRouting(t *testing.T) {
+	x := New()
+	x.GET("/info", func(c *Context) {
+		c.String(http.StatusOK, "default")
+	})
+	tenant := x.Host(":tenant.example.com")
+	tenant.Use(func(c *Context) {
+		c.SetHeader("X-Tenant-Group", "1")
+		c.NextHandle()
+	})
+	tenant.GET("/info", func(c *Context) {
+		c.String(http.StatusOK, "tenant %s", c.Param("tenant"))
+	})
+	// 精确的 host 后注册也要先于 :tenant 匹配
+	api := x.Host("api.example.com")
+	api.GET("/info", func(c *Context) {
+		c.String(http.StatusOK, "api")
+	})
+
+	cases := []struct {
+		host   string
+		want   string
+		header string
+	}{
+		{"api.example.com", "api", ""},
+		{"API.example.com:8080", "api", ""},
+		{"foo.example.com", "tenant foo", "1"},
+		{"localhost", "default", ""},
+		{"a.b.example.com", "default", ""},
+	}
+	for _, cs := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/info", nil)
+		req.Host = cs.host
+		w := httptest.NewRecorder()
+		x.ServeHTTP(w, req)
+		if w.Body.String() != cs.want {
+			t.Errorf("host %s got %q, want %q", cs.host, w.Body.String(), cs.want)
+		}
+		if w.Header().Get("X-Tenant-Group") != cs.header {
+			t.Errorf("host %s group middleware header %q", cs.host, w.Header().Get("X-Tenant-Group"))
+		}
+	}
+}
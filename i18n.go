@@ -0,0 +1,84 @@
+package xiawuyue
+
+import (
+	"html/template"
+
+	"github.com/ameamezhou/xiawuyue/xi18n"
+)
+
+// I18n detects the locale of the request, in order of the query "lang", the cookie "lang"
+// and the Accept-Language header, then c.T and the template func t translate in this locale
+func I18n(b *xi18n.Bundle) HandlerFunc {
+	return func(c *Context) {
+		tags := []string{c.Query("lang")}
+		if cookie, err := c.Req.Cookie("lang"); err == nil {
+			tags = append(tags, cookie.Value)
+		}
+		tags = append(tags, xi18n.ParseAcceptLanguage(c.Req.Header.Get("Accept-Language"))...)
+		locale := b.Match(tags...)
+		if locale == "" {
+			locale = b.DefaultLocale()
+		}
+		c.i18n = b
+		c.locale = locale
+		c.SetHeader("Content-Language", locale)
+		c.NextHandle()
+	}
+}
+
+// UseI18n installs the I18n middleware and registers the t func into the template FuncMap,
+// eg. {{t "hello" .Name}}
+func (x *Xia) UseI18n(b *xi18n.Bundle) {
+	x.i18n = b
+	if x.template != nil {
+		x.registerI18nFunc(x.template)
+	}
+	x.Use(I18n(b))
+}
+
+// registerI18nFunc 注册默认语言的 t 函数  请求中由 I18n 中间件按照请求的语言覆盖
+func (x *Xia) registerI18nFunc(t *BuildTemplate) {
+	b := x.i18n
+	t.FuncMap["t"] = func(key string, args ...interface{}) string {
+		return b.T(b.DefaultLocale(), key, args...)
+	}
+}
+
+// templateFuncs returns the funcs overridden for Render, the t func of the request locale
+// is added when the I18n middleware is installed. Without SetFuncs the template is cached
+// per locale instead of cloned for every request.
+func (c *Context) templateFuncs(t *BuildTemplate) (template.FuncMap, string) {
+	if c.i18n == nil {
+		return c.funcs, ""
+	}
+	if _, ok := t.FuncMap["t"]; !ok {
+		return c.funcs, ""
+	}
+	if _, ok := c.funcs["t"]; ok {
+		return c.funcs, ""
+	}
+	b, locale := c.i18n, c.locale
+	funcs := template.FuncMap{"t": func(key string, args ...interface{}) string {
+		return b.T(locale, key, args...)
+	}}
+	if len(c.funcs) == 0 {
+		return funcs, "locale:" + locale
+	}
+	for name, fn := range c.funcs {
+		funcs[name] = fn
+	}
+	return funcs, ""
+}
+
+// T translates key in the locale of the request, the I18n middleware must be installed
+func (c *Context) T(key string, args ...interface{}) string {
+	if c.i18n == nil {
+		return key
+	}
+	return c.i18n.T(c.locale, key, args...)
+}
+
+// Locale returns the locale detected by the I18n middleware
+func (c *Context) Locale() string {
+	return c.locale
+}
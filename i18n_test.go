@@ -0,0 +1,58 @@
+package xiawuyue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ameamezhou/xiawuyue/xi18n"
+)
+
+func TestI18n(t *testing.T) {
+	b := xi18n.NewBundle("en")
+	b.AddMessages("en", map[string]string{"hello": "hello %s"})
+	b.AddMessages("zh-CN", map[string]string{"hello": "你好 %s"})
+
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{"hello.html": `{{t "hello" .}}`})
+	x := New()
+	x.SetTemplate(&BuildTemplate{BaseDir: dir, EnableCache: true})
+	x.UseI18n(b)
+	x.GET("/text", func(c *Context) {
+		c.String(http.StatusOK, c.T("hello", "xia"))
+	})
+	x.GET("/page", func(c *Context) {
+		c.Render(http.StatusOK, "hello.html", "xia")
+	})
+
+	cases := []struct {
+		path   string
+		header string
+		want   string
+	}{
+		{"/text", "zh-CN,zh;q=0.9", "你好 xia"},
+		{"/text?lang=en", "zh-CN", "hello xia"},
+		{"/text", "", "hello xia"},
+		{"/page", "zh", "你好 xia"},
+		{"/page", "en-US", "hello xia"},
+	}
+	for _, cs := range cases {
+		req := httptest.NewRequest(http.MethodGet, cs.path, nil)
+		req.Header.Set("Accept-Language", cs.header)
+		w := httptest.NewRecorder()
+		x.ServeHTTP(w, req)
+		if w.Body.String() != cs.want {
+			t.Errorf("%s %q got %q, want %q", cs.path, cs.header, w.Body.String(), cs.want)
+		}
+	}
+
+	// 同一个 locale 复用缓存的模版  不会每个请求 Clone 一次
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/page", nil)
+		req.Header.Set("Accept-Language", "zh")
+		x.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if n := len(x.template.variants); n != 2 {
+		t.Errorf("got %d cached variants, want one per locale", n)
+	}
+}
@@ -0,0 +1,128 @@
+package xiawuyue
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/ameamezhou/xiawuyue/xlog"
+)
+
+// logLevelBody GET 返回和 PUT 接收的格式
+//
+//	{"level": "info", "modules": {"db": "debug"}}
+//
+// PUT 时 modules 中的空字符串表示模块恢复使用 logger 的 level
+type logLevelBody struct {
+	Level   string            `json:"level,omitempty"`
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+// LogLevel registers GET and PUT on pattern to read and change the levels of the
+// default xlog logger at runtime, register it on a group with authorization.
+// Only the modules created by xlog.Named can be changed.
+//
+//	curl -X PUT -H 'Content-Type: application/json' -d '{"level":"debug"}' http://127.0.0.1:8080/admin/log/level
+//	curl -X PUT 'http://127.0.0.1:8080/admin/log/level?module=db&level=warn'
+func (group *RouterGroup) LogLevel(pattern string) {
+	handler := LogLevelHandler(xlog.Default())
+	group.addRouter("GET", pattern, handler)
+	group.addRouter("PUT", pattern, handler)
+}
+
+// LogLevelHandler returns the handler of LogLevel for the logger l
+func LogLevelHandler(l *xlog.Logger) HandlerFunc {
+	return func(c *Context) {
+		if c.Method == http.MethodPut {
+			if err := putLogLevel(c, l); err != nil {
+				c.JSON(http.StatusBadRequest, ResponseXia{Code: http.StatusBadRequest, Message: err.Error()})
+				return
+			}
+		}
+		body := logLevelBody{Level: l.GetLevel().String(), Modules: make(map[string]string)}
+		for name, level := range l.ModuleLevels() {
+			body.Modules[name] = level.String()
+		}
+		c.JSON(http.StatusOK, body)
+	}
+}
+
+func putLogLevel(c *Context, l *xlog.Logger) error {
+	var body logLevelBody
+	switch {
+	case strings.HasPrefix(c.Req.Header.Get("Content-Type"), "application/json"):
+		if err := json.NewDecoder(c.Req.Body).Decode(&body); err != nil {
+			return fmt.Errorf("invalid body: %w", err)
+		}
+	case c.Req.Form.Has("level"):
+		if module := c.Req.Form.Get("module"); module != "" {
+			body.Modules = map[string]string{module: c.Req.Form.Get("level")}
+		} else {
+			body.Level = c.Req.Form.Get("level")
+		}
+	default:
+		// curl -d 默认按 form 编码发送  ServeHTTP 中 ParseForm 已经读完了 body，json 成了 form 的 key
+		var err error
+		if raw, ok := jsonFormKey(c.Req.PostForm); ok {
+			err = json.Unmarshal([]byte(raw), &body)
+		} else {
+			err = json.NewDecoder(c.Req.Body).Decode(&body)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid body, send json or level=xxx: %w", err)
+		}
+	}
+
+	// 先全部校验  避免只修改了一部分
+	var level xlog.Level
+	var err error
+	if body.Level != "" {
+		if level, err = xlog.ParseLevel(body.Level); err != nil {
+			return err
+		}
+	}
+	known := l.Modules()
+	modules := make(map[string]xlog.Level, len(body.Modules))
+	for name, s := range body.Modules {
+		// 只能修改已经存在的模块  避免请求不断向模块表中添加
+		if !slices.Contains(known, name) {
+			return fmt.Errorf("unknown module %s", name)
+		}
+		if s == "" {
+			continue
+		}
+		if modules[name], err = xlog.ParseLevel(s); err != nil {
+			return fmt.Errorf("module %s: %w", name, err)
+		}
+	}
+
+	if body.Level != "" {
+		l.SetLevel(level)
+		xlog.Warnf("log level changed to %s by %s", level, c.ClientIP())
+	}
+	for name, s := range body.Modules {
+		if s == "" {
+			l.ResetModuleLevel(name)
+			continue
+		}
+		l.SetModuleLevel(name, modules[name])
+		xlog.Warnf("log level of module %s changed to %s by %s", name, modules[name], c.ClientIP())
+	}
+	return nil
+}
+
+// jsonFormKey returns the json object sent as a form encoded body
+func jsonFormKey(form url.Values) (string, bool) {
+	if len(form) != 1 {
+		return "", false
+	}
+	for key, values := range form {
+		if strings.HasPrefix(strings.TrimSpace(key), "{") && len(values) == 1 && values[0] == "" {
+			return key, true
+		}
+	}
+	return "", false
+}
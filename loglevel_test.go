@@ -0,0 +1,74 @@
+package xiawuyue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ameamezhou/xiawuyue/xlog"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	l := xlog.NewSinkLogger(xlog.NewMemorySink(10))
+	l.SetLevel(xlog.InfoLevel)
+	db := l.Named("db")
+	x := New()
+	x.GET("/admin/log/level", LogLevelHandler(l))
+	x.PUT("/admin/log/level", LogLevelHandler(l))
+
+	do := func(method, target, body string) (int, logLevelBody) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		x.ServeHTTP(w, req)
+		var got logLevelBody
+		json.Unmarshal(w.Body.Bytes(), &got)
+		return w.Code, got
+	}
+
+	if code, got := do(http.MethodGet, "/admin/log/level", ""); code != http.StatusOK || got.Level != "info" {
+		t.Fatalf("got %d %+v", code, got)
+	}
+	code, got := do(http.MethodPut, "/admin/log/level", `{"level":"debug","modules":{"db":"error"}}`)
+	if code != http.StatusOK || got.Level != "debug" || got.Modules["db"] != "error" {
+		t.Fatalf("got %d %+v", code, got)
+	}
+	if db.Enabled(xlog.WarnLevel) {
+		t.Error("db module level should be error")
+	}
+
+	if code, _ = do(http.MethodPut, "/admin/log/level?level=loud", ""); code != http.StatusBadRequest || l.GetLevel() != xlog.DebugLevel {
+		t.Errorf("invalid level should be rejected, got %d", code)
+	}
+	if code, _ = do(http.MethodPut, "/admin/log/level", `{"modules":{"db":"loud"}}`); code != http.StatusBadRequest {
+		t.Errorf("invalid module level should be rejected, got %d", code)
+	}
+	if code, got = do(http.MethodPut, "/admin/log/level?module=db&level=", ""); code != http.StatusOK || len(got.Modules) != 0 {
+		t.Errorf("got %d %+v", code, got)
+	}
+	if code, _ = do(http.MethodPut, "/admin/log/level", `{"modules":{"new":"debug"}}`); code != http.StatusBadRequest {
+		t.Errorf("unknown module should be rejected, got %d", code)
+	}
+	if modules := l.Modules(); len(modules) != 1 {
+		t.Errorf("got modules %v", modules)
+	}
+
+	// curl -d 不带 Content-Type 时按 form 编码发送
+	req := httptest.NewRequest(http.MethodPut, "/admin/log/level", strings.NewReader(`{"level":"warn"}`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	x.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || l.GetLevel() != xlog.WarnLevel {
+		t.Errorf("got %d %s, level %v", w.Code, w.Body.String(), l.GetLevel())
+	}
+	req = httptest.NewRequest(http.MethodPut, "/admin/log/level", strings.NewReader(`{"level":"error"}`))
+	w = httptest.NewRecorder()
+	x.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || l.GetLevel() != xlog.ErrorLevel {
+		t.Errorf("got %d %s, level %v", w.Code, w.Body.String(), l.GetLevel())
+	}
+}
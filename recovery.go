@@ -5,8 +5,6 @@ import (
 	"net/http"
 	"runtime"
 	"strings"
-	"github.com/ameamezhou/xiawuyue/xlog"
-
 )
 
 // print stack trace for debug
@@ -29,7 +27,7 @@ func Recovery() HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				message := fmt.Sprintf("%s", err)
-				xlog.Errorf("%s\n\n", trace(message))
+				c.Logger().Errorf("%s\n\n", trace(message))
 				c.Fail(http.StatusInternalServerError, "Internal Server Error")
 			}
 		}()
@@ -0,0 +1,73 @@
+package xiawuyue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/ameamezhou/xiawuyue/xlog"
+)
+
+// HeaderRequestID is the header carrying the request id
+const HeaderRequestID = "X-Request-ID"
+
+// RequestID reads the request id from the X-Request-ID header or generates one,
+// and echoes it in the response, use it before AccessLog so that both are logged
+func RequestID() HandlerFunc {
+	return func(c *Context) {
+		id := c.Req.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.requestID = id
+		c.logger = nil
+		c.SetHeader(HeaderRequestID, id)
+		c.NextHandle()
+	}
+}
+
+// validRequestID 客户端传入的 id 会写进日志  只接受长度有限的可见字符
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// RequestID returns the request id set by the RequestID middleware,
+// the X-Request-ID header of the request if the middleware isn't used
+func (c *Context) RequestID() string {
+	if c.requestID != "" {
+		return c.requestID
+	}
+	return c.Req.Header.Get(HeaderRequestID)
+}
+
+// Logger returns a logger derived from the default xlog logger
+// with the request id, method, route pattern and client ip of the request
+func (c *Context) Logger() *xlog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	pattern := c.Pattern
+	if c.route != nil {
+		pattern = c.route.Pattern
+	}
+	kv := make([]interface{}, 0, 8)
+	if id := c.RequestID(); id != "" {
+		kv = append(kv, "request_id", id)
+	}
+	kv = append(kv, "method", c.Method, "route", pattern, "client_ip", c.ClientIP())
+	c.logger = xlog.Default().With(kv...)
+	return c.logger
+}
@@ -0,0 +1,58 @@
+package xiawuyue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ameamezhou/xiawuyue/xlog"
+)
+
+func TestRequestIDAndLogger(t *testing.T) {
+	mem := xlog.NewMemorySink(10)
+	xlog.SetSinks(mem)
+	defer xlog.Default().SetOutput(os.Stdout)
+
+	x := New()
+	x.Use(RequestID())
+	x.GET("/user/:id", func(c *Context) {
+		c.Logger().Info("show user")
+		c.String(http.StatusOK, c.RequestID())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/user/7", nil)
+	req.Header.Set(HeaderRequestID, "abc-123")
+	req.RemoteAddr = "10.1.1.1:1234"
+	w := httptest.NewRecorder()
+	x.ServeHTTP(w, req)
+	if got := w.Header().Get(HeaderRequestID); got != "abc-123" || w.Body.String() != "abc-123" {
+		t.Fatalf("got header %q body %q", got, w.Body.String())
+	}
+
+	var entry *xlog.Entry
+	for _, e := range mem.Entries() {
+		if e.Message == "show user" {
+			entry = &e
+		}
+	}
+	if entry == nil {
+		t.Fatalf("log not found in %q", mem.Messages())
+	}
+	fields := map[string]interface{}{}
+	for _, f := range entry.Fields {
+		fields[f.Key] = f.Value
+	}
+	if fields["request_id"] != "abc-123" || fields["method"] != "GET" || fields["route"] != "/user/:id" || fields["client_ip"] != "10.1.1.1" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+
+	// 非法的 id 重新生成
+	req = httptest.NewRequest(http.MethodGet, "/user/7", nil)
+	req.Header.Set(HeaderRequestID, "bad id\n")
+	w = httptest.NewRecorder()
+	x.ServeHTTP(w, req)
+	if got := w.Header().Get(HeaderRequestID); len(got) != 32 || got != w.Body.String() {
+		t.Errorf("expect generated id, got %q", got)
+	}
+}
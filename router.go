@@ -3,6 +3,7 @@ package xiawuyue
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 	"github.com/ameamezhou/xiawuyue/xlog"
@@ -12,16 +13,32 @@ import (
 // 引入前缀树
 type router struct {
 	roots    map[string]*Trie
-	handlers map[string]HandlerFunc
+	handlers map[string]*Route
+	named    map[string]*Route // 命名路由  name => route
+	routes   []*Route          // 按注册顺序记录全部路由
+	hosts    []string          // 注册过路由的 host pattern
+}
+
+// Route is returned by GET/POST... so that the route can be named
+type Route struct {
+	Method  string
+	Pattern string
+	Host    string // host pattern, empty means any host
+	name    string
+	cmdID   int // RegisterHandlers 注册的 Handler.CmdID
+	handler HandlerFunc
+	router  *router
 }
 
 // roots key eg, roots['GET'] roots['POST']
 // handlers key eg, handlers['GET-/p/:lang/doc'], handlers['POST-/p/book']
+// host routes use method@host as the method part, eg. roots['GET@api.example.com']
 
 func newRouter() *router {
 	return &router{
 		roots:    make(map[string]*Trie),
-		handlers: make(map[string]HandlerFunc),
+		handlers: make(map[string]*Route),
+		named:    make(map[string]*Route),
 	}
 }
 
@@ -41,20 +58,116 @@ func parsePattern(pattern string) []string {
 	return parts
 }
 
-func (r *router) addRouter(method string, pattern string, handler HandlerFunc) {
+func (r *router) addRouter(method string, pattern string, handler HandlerFunc) *Route {
+	return r.addHostRouter("", method, pattern, handler)
+}
+
+func (r *router) addHostRouter(host string, method string, pattern string, handler HandlerFunc) *Route {
 	if len(pattern) > 0 && pattern[0] != '/' {
 		pattern = "/" + pattern
 	}
 	parts := parsePattern(pattern)
 
-	key := method + "-" + pattern
-	_, ok := r.roots[method]
+	rootKey := hostRootKey(method, host)
+	key := rootKey + "-" + pattern
+	_, ok := r.roots[rootKey]
 	if !ok {
-		r.roots[method] = &Trie{}
+		r.roots[rootKey] = &Trie{}
+		if host != "" && !r.hasHost(host) {
+			r.addHost(host)
+		}
+	}
+	xlog.Infof("Add new router %4s - %s%s", method, host, pattern)
+	r.roots[rootKey].insert(parts, pattern, 0)
+
+	// 重复注册的时候覆盖之前的 handler  之前的名字也去掉，需要的话重新命名
+	if rt, ok := r.handlers[key]; ok {
+		rt.handler = handler
+		if rt.name != "" {
+			delete(r.named, rt.name)
+			rt.name = ""
+		}
+		return rt
+	}
+	rt := &Route{Method: method, Pattern: pattern, Host: host, handler: handler, router: r}
+	r.handlers[key] = rt
+	r.routes = append(r.routes, rt)
+	return rt
+}
+
+// Name names the route, the name can be used by URLFor to build the url
+// a name can only be used once
+func (rt *Route) Name(name string) *Route {
+	if _, ok := rt.router.named[name]; ok {
+		panic(fmt.Sprintf("route name %s is already used", name))
+	}
+	rt.name = name
+	rt.router.named[name] = rt
+	return rt
+}
+
+func (r *router) urlFor(name string, params ...interface{}) (string, error) {
+	rt, ok := r.named[name]
+	if !ok {
+		return "", fmt.Errorf("route %s not found", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("route %s params must be key value pairs", name)
+	}
+	values := make(map[string]string)
+	keys := make([]string, 0, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key := fmt.Sprint(params[i])
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = fmt.Sprint(params[i+1])
+	}
+
+	// 按 Trie.Path 中记录的 pattern 逐段替换
+	parts := parsePattern(rt.Pattern)
+	for i, part := range parts {
+		if part[0] != ':' && part[0] != '*' {
+			continue
+		}
+		key := part[1:]
+		value, ok := values[key]
+		if !ok {
+			if part[0] == '*' {
+				// 通配符可以为空
+				parts[i] = ""
+				continue
+			}
+			return "", fmt.Errorf("route %s missing param %s", name, key)
+		}
+		delete(values, key)
+		if part[0] == '*' {
+			// 通配符允许包含 /  只转义每一段
+			segs := strings.Split(value, "/")
+			for j := range segs {
+				segs[j] = url.PathEscape(segs[j])
+			}
+			parts[i] = strings.Join(segs, "/")
+		} else {
+			parts[i] = url.PathEscape(value)
+		}
+	}
+	path := "/" + strings.Join(parts, "/")
+	if strings.HasSuffix(rt.Pattern, "/") && !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	// 剩余的参数拼到 query 中
+	query := url.Values{}
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			query.Add(key, value)
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
 	}
-	xlog.Infof("Add new router %4s - %s", method, pattern)
-	r.roots[method].insert(parts, pattern, 0)
-	r.handlers[key] = handler
+	return path, nil
 }
 
 func (r *router) getRouter(method string, path string) (*Trie, map[string]string, error) {
@@ -78,7 +191,32 @@ func (r *router) getRouter(method string, path string) (*Trie, map[string]string
 	return nil, nil, nil
 }
 
-func (r *router) handle(c *Context) {
+// find looks up the route of the request and fills c.Params,
+// returns nil if no route matched
+func (r *router) find(c *Context) *Route {
+	// 先匹配 host 路由  没有匹配上再走默认路由
+	host := requestHost(c.Req)
+	for _, pattern := range r.hosts {
+		hostParams, ok := matchHost(pattern, host)
+		if !ok {
+			continue
+		}
+		rootKey := hostRootKey(c.Method, pattern)
+		if _, ok = r.roots[rootKey]; !ok {
+			continue
+		}
+		t, params, _ := r.getRouter(rootKey, c.Pattern)
+		if t != nil {
+			for k, v := range hostParams {
+				if _, ok = params[k]; !ok {
+					params[k] = v
+				}
+			}
+			c.Params = params
+			return r.handlers[rootKey+"-"+t.Path]
+		}
+	}
+
 	t, params, err := r.getRouter(c.Method, c.Pattern)
 	if err != nil {
 		xlog.Error(err)
@@ -86,14 +224,13 @@ func (r *router) handle(c *Context) {
 	if t != nil {
 		c.Params = params
 		key := c.Method + "-" + t.Path
-		c.middlewares = append(c.middlewares, r.handlers[key])
-	} else {
-		c.middlewares = append(c.middlewares, func(c *Context) {
-			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Pattern)
-		})
-	}
-	// c.NextHandle()
-	Recovery()(c)
+		return r.handlers[key]
+	}
+	return nil
+}
+
+func notFoundHandler(c *Context) {
+	c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Pattern)
 }
 
 func TimeLogger(c *Context) {
@@ -0,0 +1,88 @@
+package xiawuyue
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestURLFor(t *testing.T) {
+	x := New()
+	x.GET("/user/:id", func(c *Context) {}).Name("user.show")
+	g := x.Group("/static")
+	g.GET("/*filepath", func(c *Context) {}).Name("static")
+
+	cases := []struct {
+		name   string
+		params []interface{}
+		want   string
+	}{
+		{"user.show", []interface{}{"id", 12}, "/user/12"},
+		{"user.show", []interface{}{"id", "a b", "tab", "info"}, "/user/a%20b?tab=info"},
+		{"static", []interface{}{"filepath", "css/main.css"}, "/static/css/main.css"},
+	}
+	for _, cs := range cases {
+		got, err := x.URLFor(cs.name, cs.params...)
+		if err != nil {
+			t.Fatalf("URLFor(%s) error: %v", cs.name, err)
+		}
+		if got != cs.want {
+			t.Errorf("URLFor(%s) = %s, want %s", cs.name, got, cs.want)
+		}
+	}
+
+	if _, err := x.URLFor("user.show"); err == nil {
+		t.Error("URLFor without required param should fail")
+	}
+	if _, err := x.URLFor("nothing"); err == nil {
+		t.Error("URLFor of unknown route should fail")
+	}
+}
+
+func TestURLForTemplate(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(`<a href="{{url "user.show" "id" .Data}}">user</a>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := New()
+	x.GET("/user/:id", func(c *Context) {}).Name("user.show")
+	tpl := &BuildTemplate{BaseDir: dir}
+	x.SetTemplate(tpl)
+
+	p, err := tpl.Get("index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err = p.Execute(buf, ResponseXia{Data: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != `<a href="/user/7">user</a>` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestRouterParams(t *testing.T) {
+	x := New()
+	var params map[string]string
+	x.GET("/user/:id", func(c *Context) { params = c.Params })
+	x.GET("/files/*filepath", func(c *Context) { params = c.Params })
+
+	cases := map[string]map[string]string{
+		"/user/12":       {"id": "12"},
+		"/files/a/b.txt": {"filepath": "a/b.txt"},
+	}
+	for path, want := range cases {
+		params = nil
+		x.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		for k, v := range want {
+			if params[k] != v {
+				t.Errorf("%s param %s = %q, want %q", path, k, params[k], v)
+			}
+		}
+	}
+}
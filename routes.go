@@ -0,0 +1,102 @@
+package xiawuyue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Method      string   `json:"method"`
+	Pattern     string   `json:"pattern"`
+	Host        string   `json:"host,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Handler     string   `json:"handler"`
+	Middlewares []string `json:"middlewares"`
+}
+
+// Routes returns all registered routes, sorted by pattern and method
+func (x *Xia) Routes() []RouteInfo {
+	infos := make([]RouteInfo, 0, len(x.router.routes))
+	for _, rt := range x.router.routes {
+		info := RouteInfo{
+			Method:      rt.Method,
+			Pattern:     rt.Pattern,
+			Host:        rt.Host,
+			Name:        rt.name,
+			Handler:     nameOfFunction(rt.handler),
+			Middlewares: make([]string, 0),
+		}
+		// 和 ServeHTTP 一样按照前缀匹配 group 的中间件
+		for _, g := range x.groups {
+			if g.host != "" && g.host != rt.Host {
+				continue
+			}
+			if strings.HasPrefix(rt.Pattern, g.prefix) {
+				for _, m := range g.middlewares {
+					info.Middlewares = append(info.Middlewares, nameOfFunction(m))
+				}
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Pattern != infos[j].Pattern {
+			return infos[i].Pattern < infos[j].Pattern
+		}
+		if infos[i].Host != infos[j].Host {
+			return infos[i].Host < infos[j].Host
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+// PrintRoutes writes the route table to w, format can be "text" or "json"
+func (x *Xia) PrintRoutes(w io.Writer, format string) error {
+	routes := x.Routes()
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(routes)
+	case "text", "":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tHOST\tPATTERN\tNAME\tHANDLER\tMIDDLEWARES")
+		for _, rt := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", rt.Method, rt.Host, rt.Pattern, rt.Name, rt.Handler, strings.Join(rt.Middlewares, ","))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown route table format %s", format)
+	}
+}
+
+// DebugRoutes registers a GET endpoint which dumps the route table,
+// json by default, ?format=text for the text table
+func (group *RouterGroup) DebugRoutes(pattern string) *Route {
+	return group.GET(pattern, func(c *Context) {
+		format := c.Query("format")
+		if format == "text" {
+			c.SetHeader("Content-Type", "text/plain; charset=utf-8")
+			c.Status(http.StatusOK)
+			group.xia.PrintRoutes(c.Writer, format)
+			return
+		}
+		c.JSON(http.StatusOK, group.xia.Routes())
+	})
+}
+
+func nameOfFunction(f interface{}) string {
+	if f == nil {
+		return ""
+	}
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
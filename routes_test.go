@@ -0,0 +1,56 @@
+package xiawuyue
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	x := New()
+	x.GET("/user/:id", testPost).Name("user.show")
+	api := x.Group("/api")
+	api.Use(TimeLogger)
+	api.POST("/book", testPost)
+	api.DebugRoutes("/routes")
+
+	routes := x.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("got %d routes, want 3", len(routes))
+	}
+	book := routes[0]
+	if book.Method != "POST" || book.Pattern != "/api/book" {
+		t.Fatalf("unexpected first route %+v", book)
+	}
+	if !strings.HasSuffix(book.Handler, ".testPost") {
+		t.Errorf("handler name %s", book.Handler)
+	}
+	// group "/" from New and group "/api"
+	if len(book.Middlewares) != 2 || !strings.HasSuffix(book.Middlewares[1], ".TimeLogger") {
+		t.Errorf("middlewares %v", book.Middlewares)
+	}
+	if routes[2].Name != "user.show" {
+		t.Errorf("route name %s", routes[2].Name)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := x.PrintRoutes(buf, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "/api/book") {
+		t.Errorf("text table missing route: %s", buf.String())
+	}
+
+	w := httptest.NewRecorder()
+	x.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/routes", nil))
+	var got []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err, w.Body.String())
+	}
+	if len(got) != 3 {
+		t.Errorf("debug endpoint returned %d routes", len(got))
+	}
+}
@@ -0,0 +1,215 @@
+package xiawuyue
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StaticConfig configures how static files are served
+type StaticConfig struct {
+	Root fs.FS // 静态文件所在的文件系统
+	// CacheControl 按扩展名设置 Cache-Control，key 是 ".js" 这样的扩展名，"*" 是其他文件的默认值
+	// eg. {".js": "public, max-age=31536000, immutable", "*": "no-cache"}
+	CacheControl map[string]string
+	// Precompressed 存在 xxx.br / xxx.gz 文件时，按照 Accept-Encoding 返回压缩好的文件
+	Precompressed bool
+	// DisableListing 关闭目录列表，没有 index.html 的目录返回 404
+	DisableListing bool
+	// SPAFallback 找不到文件的时候返回的文件，单页应用一般是 "index.html"
+	SPAFallback string
+}
+
+// 预压缩文件的后缀  按照优先级排列
+var precompressedEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+type staticHandler struct {
+	conf       StaticConfig
+	fileServer http.Handler // 目录列表交给 http.FileServer
+	etags      sync.Map     // name|size|modtime => etag
+}
+
+func newStaticHandler(absolutePath string, conf StaticConfig) *staticHandler {
+	return &staticHandler{
+		conf:       conf,
+		fileServer: http.StripPrefix(strings.TrimSuffix(absolutePath, "/"), http.FileServerFS(conf.Root)),
+	}
+}
+
+// Static serves static files under root dir on disk
+func (group *RouterGroup) Static(relativePath string, root string) {
+	group.StaticWithConfig(relativePath, StaticConfig{Root: os.DirFS(root)})
+}
+
+// StaticFS serves static files from fsys, eg. a //go:embed embed.FS
+func (group *RouterGroup) StaticFS(relativePath string, fsys fs.FS) {
+	group.StaticWithConfig(relativePath, StaticConfig{Root: fsys})
+}
+
+// StaticWithConfig serves static files with ETag, Cache-Control, precompressed files and SPA fallback
+func (group *RouterGroup) StaticWithConfig(relativePath string, conf StaticConfig) {
+	h := newStaticHandler(path.Join(group.prefix, relativePath), conf)
+	handler := func(c *Context) {
+		h.serve(c, c.Param("filepath"))
+	}
+	urlPattern := path.Join(relativePath, "/*filepath")
+	// Register GET and HEAD handlers
+	group.GET(relativePath, handler)
+	group.GET(urlPattern, handler)
+	group.addRouter(http.MethodHead, relativePath, handler)
+	group.addRouter(http.MethodHead, urlPattern, handler)
+}
+
+// StaticFile serves a single file on disk
+func (group *RouterGroup) StaticFile(relativePath string, file string) {
+	dir, name := path.Split(file)
+	if dir == "" {
+		dir = "."
+	}
+	group.StaticFileFS(relativePath, name, os.DirFS(dir))
+}
+
+// StaticFileFS serves a single file in fsys
+func (group *RouterGroup) StaticFileFS(relativePath string, file string, fsys fs.FS) {
+	h := newStaticHandler(path.Join(group.prefix, relativePath), StaticConfig{Root: fsys})
+	handler := func(c *Context) {
+		h.serve(c, file)
+	}
+	group.GET(relativePath, handler)
+	group.addRouter(http.MethodHead, relativePath, handler)
+}
+
+func (h *staticHandler) serve(c *Context, name string) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(h.conf.Root, name)
+	if err == nil && info.IsDir() {
+		index := path.Join(name, "index.html")
+		if indexInfo, err := fs.Stat(h.conf.Root, index); err == nil && !indexInfo.IsDir() {
+			h.serveFile(c, index, indexInfo)
+			return
+		}
+		if !h.conf.DisableListing {
+			h.fileServer.ServeHTTP(c.Writer, c.Req)
+			return
+		}
+		err = fs.ErrNotExist
+	}
+	if err != nil && h.conf.SPAFallback != "" {
+		name = h.conf.SPAFallback
+		info, err = fs.Stat(h.conf.Root, name)
+	}
+	if err != nil || info.IsDir() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	h.serveFile(c, name, info)
+}
+
+func (h *staticHandler) serveFile(c *Context, name string, info fs.FileInfo) {
+	header := c.Writer.Header()
+	ext := path.Ext(name)
+	if ctype := mime.TypeByExtension(ext); ctype != "" {
+		header.Set("Content-Type", ctype)
+	}
+	if cacheControl, ok := h.conf.CacheControl[ext]; ok {
+		header.Set("Cache-Control", cacheControl)
+	} else if cacheControl, ok = h.conf.CacheControl["*"]; ok {
+		header.Set("Cache-Control", cacheControl)
+	}
+
+	// 优先返回预压缩的文件
+	servedName := name
+	if h.conf.Precompressed {
+		header.Add("Vary", "Accept-Encoding")
+		acceptEncoding := c.Req.Header.Get("Accept-Encoding")
+		for _, pc := range precompressedEncodings {
+			if !acceptsEncoding(acceptEncoding, pc.encoding) {
+				continue
+			}
+			if zipInfo, err := fs.Stat(h.conf.Root, name+pc.ext); err == nil && !zipInfo.IsDir() {
+				header.Set("Content-Encoding", pc.encoding)
+				servedName, info = name+pc.ext, zipInfo
+				break
+			}
+		}
+	}
+
+	file, err := h.conf.Root.Open(servedName)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		c.Fail(http.StatusInternalServerError, "file %s is not seekable", name)
+		return
+	}
+
+	etag, err := h.etag(servedName, info, content)
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	header.Set("ETag", etag)
+	c.StatusCode = http.StatusOK
+	// ServeContent 处理 If-None-Match / Range / HEAD
+	http.ServeContent(c.Writer, c.Req, name, info.ModTime(), content)
+}
+
+// etag 用文件内容的 sha256 做强 ETag，按照 name size modtime 缓存
+func (h *staticHandler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := fmt.Sprintf("%s|%d|%d", name, info.Size(), info.ModTime().UnixNano())
+	if etag, ok := h.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	h.etags.Store(key, etag)
+	return etag, nil
+}
+
+// acceptsEncoding 判断 Accept-Encoding 是否接受 encoding，q=0 表示不接受
+func acceptsEncoding(header, encoding string) bool {
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimSpace(item)
+		name, params, _ := strings.Cut(item, ";")
+		name = strings.TrimSpace(name)
+		if !strings.EqualFold(name, encoding) && name != "*" {
+			continue
+		}
+		params = strings.TrimSpace(params)
+		if q, ok := strings.CutPrefix(params, "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package xiawuyue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"css/main.css": {Data: []byte("body{}")},
+		"robots.txt":   {Data: []byte("User-agent: *")},
+	}
+	x := New()
+	x.Group("/assets").StaticFS("/", fsys)
+	x.StaticFileFS("/robots.txt", "robots.txt", fsys)
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/assets/css/main.css", http.StatusOK, "body{}"},
+		{"/assets/css/none.css", http.StatusNotFound, ""},
+		{"/robots.txt", http.StatusOK, "User-agent: *"},
+	}
+	for _, cs := range cases {
+		w := httptest.NewRecorder()
+		x.ServeHTTP(w, httptest.NewRequest(http.MethodGet, cs.path, nil))
+		if w.Code != cs.code || (cs.body != "" && w.Body.String() != cs.body) {
+			t.Errorf("%s got %d %q", cs.path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestStaticWithConfig(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":   {Data: []byte("<html>app</html>")},
+		"app.js":       {Data: []byte("console.log(1)")},
+		"app.js.gz":    {Data: []byte("gzipped")},
+		"docs/a.txt":   {Data: []byte("a")},
+		"about/x.html": {Data: []byte("x")},
+		"about/y.html": {Data: []byte("y")},
+	}
+	x := New()
+	x.Group("/app").StaticWithConfig("/", StaticConfig{
+		Root:           fsys,
+		CacheControl:   map[string]string{".js": "public, max-age=31536000", "*": "no-cache"},
+		Precompressed:  true,
+		DisableListing: true,
+		SPAFallback:    "index.html",
+	})
+
+	serve := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		x.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/app/app.js", map[string]string{"Accept-Encoding": "br, gzip"})
+	if w.Body.String() != "gzipped" || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("precompressed got %q, encoding %q", w.Body.String(), w.Header().Get("Content-Encoding"))
+	}
+	if w.Header().Get("Cache-Control") != "public, max-age=31536000" {
+		t.Errorf("cache control %q", w.Header().Get("Cache-Control"))
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/javascript") {
+		t.Errorf("content type %q", w.Header().Get("Content-Type"))
+	}
+
+	w = serve("/app/app.js", nil)
+	etag := w.Header().Get("ETag")
+	if w.Body.String() != "console.log(1)" || etag == "" || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("plain got %q, etag %q", w.Body.String(), etag)
+	}
+	w = serve("/app/app.js", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match got %d", w.Code)
+	}
+
+	// 目录列表被关闭  未知路径回退到 index.html
+	for _, path := range []string{"/app/about/", "/app/users/12", "/app"} {
+		w = serve(path, nil)
+		if w.Code != http.StatusOK || w.Body.String() != "<html>app</html>" || w.Header().Get("Cache-Control") != "no-cache" {
+			t.Errorf("%s got %d %q", path, w.Code, w.Body.String())
+		}
+	}
+}
@@ -3,26 +3,53 @@ package xiawuyue
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"html/template"
+	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 )
 
+// 页面继承 layout 的声明  需要写在模版开头，eg. {{/* extends "base.html" */}}
+var extendsRegexp = regexp.MustCompile(`^\s*{{-?\s*/\*\s*extends\s+"([^"]+)"\s*\*/\s*-?}}`)
+
 type BuildTemplate struct {
-	BaseDir     string           // base dir
+	BaseDir     string           // base dir，设置了 FS 的时候是 FS 中的目录
+	FS          fs.FS            // 模版所在的文件系统，eg. //go:embed 的 embed.FS，为空时从磁盘读取
 	EnableCache bool             // 是否启用缓存
 	FuncMap     template.FuncMap // template 中用到的 Func map
 	// 在 Go 的 `html/template` 包中，`template.FuncMap` 是一个映射表，用于存储模板函数及其对应的实现函数。具体来说，它可以用于在模板中注册自定义的函数，以便在模板渲染时使用。
 	//
 	//`template.FuncMap` 的作用是将模板函数名与实际的函数实现绑定起来，以便在模板中使用。例如，我们可以定义一个 `add` 函数实现加法运算，然后将其与模板函数名 `add` 绑定起来，这样在模板中就可以使用 `{{ add 1 2 }}` 的语法进行加法运算。
 
+	// LayoutDir 是 layout 所在目录，相对 BaseDir，目录下的模版按照相对 LayoutDir 的路径命名
+	// 页面通过 {{/* extends "base.html" */}} 继承 layout，用 {{define "xxx"}} 覆盖 layout 中的 {{block "xxx" .}}
+	LayoutDir string
+	// PartialGlob 是公共片段的 glob，相对 BaseDir，eg. "partials/*.html"
+	// 片段按照相对 BaseDir 的路径命名，一般在片段中 {{define "header"}} 给页面使用
+	PartialGlob string
+	// DevMode 开发模式  模版解析或执行出错时返回错误页面  而不是 panic，配合 Watch 热加载使用
+	DevMode bool
+
 	cacheMap map[string]*template.Template // 缓存map
+	masters  map[string]*template.Template // 缓存模版未执行过的副本，执行过的 html/template 不能再 Clone，需要覆盖 FuncMap 的时候用它 Clone
+	variants map[string]*template.Template // 覆盖了 FuncMap 的副本按 name + variant 缓存，eg. 每个 locale 一份
+	shared   *template.Template            // layouts 和 partials，每个页面 clone 一份再解析
+	version  int                           // 缓存失效一次加一  避免把失效前解析的模版写回缓存
 	wrMux    sync.RWMutex                  // 模版读写锁   读写之前要先拿锁
 }
 
 func (t *BuildTemplate) baseDirCheck() error {
+	if t.FS != nil {
+		// 使用 FS 的时候 BaseDir 是 FS 中的目录  可以为空
+		return nil
+	}
 	if t.BaseDir == "" {
 		return errors.New("you should set template's base dir attr")
 	}
@@ -32,64 +59,350 @@ func (t *BuildTemplate) baseDirCheck() error {
 	return nil
 }
 
+// root 返回模版所在的文件系统  根目录就是 BaseDir
+func (t *BuildTemplate) root() (fs.FS, error) {
+	if err := t.baseDirCheck(); err != nil {
+		return nil, err
+	}
+	if t.FS == nil {
+		return os.DirFS(t.BaseDir), nil
+	}
+	dir := strings.Trim(t.BaseDir, "/")
+	if dir == "" || dir == "." {
+		return t.FS, nil
+	}
+	return fs.Sub(t.FS, dir)
+}
+
+func (t *BuildTemplate) readFile(name string) ([]byte, error) {
+	root, err := t.root()
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(root, strings.TrimPrefix(name, "/"))
+}
+
+func (t *BuildTemplate) layoutPrefix() string {
+	return strings.TrimSuffix(path.Clean(filepath.ToSlash(t.LayoutDir)), "/") + "/"
+}
+
+// layoutFiles 返回 LayoutDir 下全部的文件  相对 BaseDir
+func (t *BuildTemplate) layoutFiles() ([]string, error) {
+	if t.LayoutDir == "" {
+		return nil, nil
+	}
+	root, err := t.root()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	err = fs.WalkDir(root, strings.TrimSuffix(t.layoutPrefix(), "/"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// partialFiles 返回匹配 PartialGlob 的文件  相对 BaseDir
+func (t *BuildTemplate) partialFiles() ([]string, error) {
+	if t.PartialGlob == "" {
+		return nil, nil
+	}
+	root, err := t.root()
+	if err != nil {
+		return nil, err
+	}
+	return fs.Glob(root, filepath.ToSlash(t.PartialGlob))
+}
+
+// isShared 判断文件是不是 layout 或者 partial
+func (t *BuildTemplate) isShared(name string) bool {
+	if t.LayoutDir != "" && strings.HasPrefix(name, t.layoutPrefix()) {
+		return true
+	}
+	if t.PartialGlob != "" {
+		if ok, _ := path.Match(filepath.ToSlash(t.PartialGlob), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newShared 解析 partials 和 layouts 到同一个模版集合
+func (t *BuildTemplate) newShared() (*template.Template, error) {
+	root := template.New("")
+	if t.FuncMap != nil {
+		root.Funcs(t.FuncMap)
+	}
+
+	partials, err := t.partialFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range partials {
+		data, err := t.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = root.New(name).Parse(string(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	layouts, err := t.layoutFiles()
+	if err != nil {
+		return nil, err
+	}
+	layoutPrefix := t.layoutPrefix()
+	for _, name := range layouts {
+		data, err := t.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = root.New(strings.TrimPrefix(name, layoutPrefix)).Parse(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// cacheEnabled 读 EnableCache  Watch 可能在处理请求的时候打开缓存，所以要拿锁
+func (t *BuildTemplate) cacheEnabled() bool {
+	t.wrMux.RLock()
+	defer t.wrMux.RUnlock()
+	return t.EnableCache
+}
+
+func (t *BuildTemplate) enableCache() {
+	t.wrMux.Lock()
+	t.EnableCache = true
+	t.wrMux.Unlock()
+}
+
+func (t *BuildTemplate) getShared() (*template.Template, error) {
+	if !t.cacheEnabled() {
+		return t.newShared()
+	}
+	t.wrMux.RLock()
+	shared := t.shared
+	t.wrMux.RUnlock()
+	if shared != nil {
+		return shared, nil
+	}
+
+	shared, err := t.newShared()
+	if err != nil {
+		return nil, err
+	}
+	t.wrMux.Lock()
+	if t.shared == nil {
+		t.shared = shared
+	}
+	shared = t.shared
+	t.wrMux.Unlock()
+	return shared, nil
+}
+
+// Get returns the template of the page, the page is parsed in its own copy of
+// the layouts and partials, if the page extends a layout the layout template is returned
 func (t *BuildTemplate) Get(name string) (*template.Template, error) {
 	var err error
 	err = t.baseDirCheck()
 	if err != nil {
 		return nil, err
 	}
-	path := t.BaseDir + name
 	var p *template.Template
-	if t.EnableCache {
+	var version int
+	if t.cacheEnabled() {
 		var ok = false
 		t.wrMux.RLock()
 		if t.cacheMap != nil {
 			p, ok = t.cacheMap[name]
 
 		}
+		version = t.version
 		t.wrMux.RUnlock()
 		if ok {
 			return p, nil
 		}
 	}
 
-	data, err := os.ReadFile(path)
+	data, err := t.readFile(name)
 	if err != nil {
 		return nil, err
 	}
 
-	if t.FuncMap != nil {
-		p = template.Must(template.New(name).Funcs(t.FuncMap).Parse(string(data)))
-	} else {
-		p = template.Must(template.New(name).Parse(string(data)))
+	shared, err := t.getShared()
+	if err != nil {
+		return nil, err
+	}
+	// 每个页面一份独立的模版集合  避免页面之间的 define 互相覆盖
+	set, err := shared.Clone()
+	if err != nil {
+		return nil, err
+	}
+	p, err = set.New(name).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if m := extendsRegexp.FindSubmatch(data); m != nil {
+		layout := string(m[1])
+		p = set.Lookup(layout)
+		if p == nil {
+			return nil, fmt.Errorf("template %s extends unknown layout %s", name, layout)
+		}
 	}
 
-	if t.EnableCache {
+	if t.cacheEnabled() {
 		t.wrMux.Lock()
 		if t.cacheMap == nil {
 			t.cacheMap = make(map[string]*template.Template)
 		}
-		t.cacheMap[name] = p
+		if t.masters == nil {
+			t.masters = make(map[string]*template.Template)
+		}
+		if t.version == version {
+			if master, err := p.Clone(); err == nil {
+				t.masters[name] = master
+			}
+			t.cacheMap[name] = p
+		}
 		t.wrMux.Unlock()
 	}
 
 	return p, nil
 }
 
-// template 写入 writer
+// Precompile parses all the pages under BaseDir into cache at startup and turns EnableCache on,
+// layouts and partials are not treated as pages
+func (t *BuildTemplate) Precompile() error {
+	root, err := t.root()
+	if err != nil {
+		return err
+	}
+	t.enableCache()
+	errs := make([]error, 0)
+	err = fs.WalkDir(root, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || t.isShared(name) {
+			return nil
+		}
+		if _, err = t.Get(name); err != nil {
+			errs = append(errs, fmt.Errorf("precompile template %s: %w", name, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
 
-func (t *BuildTemplate) WriterBuffer(w http.ResponseWriter, filename string, data ResponseXia) {
-	p, err := t.Get(filename)
+// withFuncs 返回覆盖了 funcs 的模版，funcs 只能覆盖 FuncMap 中已经声明过的函数
+func (t *BuildTemplate) withFuncs(name string, funcs template.FuncMap) (*template.Template, error) {
+	p, err := t.Get(name)
+	if err != nil || len(funcs) == 0 {
+		return p, err
+	}
+	if t.cacheEnabled() {
+		t.wrMux.RLock()
+		master, ok := t.masters[name]
+		t.wrMux.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("template %s is not cached", name)
+		}
+		if p, err = master.Clone(); err != nil {
+			return nil, err
+		}
+	}
+	// 没有缓存的时候 Get 每次都重新解析  可以直接修改
+	return p.Funcs(funcs), nil
+}
+
+// withVariant 和 withFuncs 一样，但是按 variant 缓存 Clone 的结果，同一个 variant 的 funcs 必须相同
+func (t *BuildTemplate) withVariant(name, variant string, funcs template.FuncMap) (*template.Template, error) {
+	if !t.cacheEnabled() || variant == "" || len(funcs) == 0 {
+		return t.withFuncs(name, funcs)
+	}
+	key := name + "\x00" + variant
+	t.wrMux.RLock()
+	p, ok := t.variants[key]
+	version := t.version
+	t.wrMux.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	p, err := t.withFuncs(name, funcs)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	t.wrMux.Lock()
+	if t.version == version {
+		if t.variants == nil {
+			t.variants = make(map[string]*template.Template)
+		}
+		t.variants[key] = p
 	}
+	t.wrMux.Unlock()
+	return p, nil
+}
 
-	buffer := &bytes.Buffer{}
-	err = p.Execute(buffer, data)
+// Execute executes the template into w, funcs overrides the functions declared in FuncMap for this execution
+func (t *BuildTemplate) Execute(w io.Writer, name string, data interface{}, funcs template.FuncMap) error {
+	return t.executeVariant(w, name, data, funcs, "")
+}
+
+func (t *BuildTemplate) executeVariant(w io.Writer, name string, data interface{}, funcs template.FuncMap, variant string) error {
+	p, err := t.withVariant(name, variant, funcs)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	return p.Execute(w, data)
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	return buffer
+}
+
+func putBuffer(buffer *bytes.Buffer) {
+	// 太大的 buffer 不放回去  避免一直占用内存
+	if buffer.Cap() > 1<<20 {
+		return
+	}
+	bufferPool.Put(buffer)
+}
+
+// template 写入 writer
+// 出错时返回 error  DevMode 下先写出错误页，否则什么都不写  由调用方决定怎么响应
+
+func (t *BuildTemplate) WriterBuffer(w http.ResponseWriter, filename string, data ResponseXia) error {
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	err := t.Execute(buffer, filename, data, nil)
+	if err == nil {
+		// 设置 http header
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		_, err = w.Write(buffer.Bytes())
+		return err
+	}
+	if t.DevMode {
+		writeDebugPage(w, filename, err)
 	}
-	// 设置 http header
-	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
-	w.Write(buffer.Bytes())
+	return err
 }
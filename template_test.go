@@ -0,0 +1,85 @@
+package xiawuyue
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func writeTemplateFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTemplateLayouts(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{
+		"layouts/base.html":    `<title>{{block "title" .}}default{{end}}</title>{{template "header" .}}{{block "content" .}}{{end}}`,
+		"partials/header.html": `{{define "header"}}<h1>{{.Message}}</h1>{{end}}`,
+		"index.html":           `{{/* extends "base.html" */}}{{define "title"}}index{{end}}{{define "content"}}<p>{{.Data}}</p>{{end}}`,
+		"about.html":           `{{/* extends "base.html" */}}{{define "content"}}about{{end}}`,
+		"plain.html":           `{{template "header" .}}plain`,
+	})
+	tpl := &BuildTemplate{BaseDir: dir, EnableCache: true, LayoutDir: "layouts", PartialGlob: "partials/*.html"}
+
+	cases := map[string]string{
+		"index.html": `<title>index</title><h1>hi</h1><p>data</p>`,
+		"about.html": `<title>default</title><h1>hi</h1>about`,
+		"plain.html": `<h1>hi</h1>plain`,
+	}
+	// 执行两遍  第二遍走缓存
+	for i := 0; i < 2; i++ {
+		for name, want := range cases {
+			p, err := tpl.Get(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := &bytes.Buffer{}
+			if err = p.Execute(buf, ResponseXia{Data: "data", Message: "hi"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != want {
+				t.Errorf("%s got %q, want %q", name, got, want)
+			}
+		}
+	}
+
+	writeTemplateFiles(t, dir, map[string]string{"bad.html": `{{/* extends "nothing.html" */}}`})
+	if _, err := tpl.Get("bad.html"); err == nil {
+		t.Error("extending unknown layout should fail")
+	}
+}
+
+func TestTemplateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"views/layouts/base.html": {Data: []byte(`<body>{{block "content" .}}{{end}}</body>`)},
+		"views/index.html":        {Data: []byte(`{{/* extends "base.html" */}}{{define "content"}}{{.Message}}{{end}}`)},
+	}
+	tpl := &BuildTemplate{FS: fsys, BaseDir: "views", LayoutDir: "layouts"}
+	if err := tpl.Precompile(); err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, "index.html", ResponseXia{Message: "embed"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<body>embed</body>" {
+		t.Errorf("got %q", buf.String())
+	}
+
+	fsys["views/bad.html"] = &fstest.MapFile{Data: []byte(`{{ .Message `)}
+	if err := tpl.Precompile(); err == nil {
+		t.Error("precompile should report the broken template")
+	}
+}
@@ -0,0 +1,125 @@
+package xiawuyue
+
+import (
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ameamezhou/xiawuyue/xlog"
+)
+
+// Watch polls the mtimes under BaseDir every interval and drops the changed templates from cache,
+// a changed layout or partial drops the whole cache. Watch turns EnableCache on under the
+// template lock, so it can be started while requests are served, call the returned func to stop watching
+func (t *BuildTemplate) Watch(interval time.Duration) (func(), error) {
+	if _, err := t.root(); err != nil {
+		return nil, err
+	}
+	t.enableCache()
+	last, err := t.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			current, err := t.snapshot()
+			if err != nil {
+				xlog.Errorf("template watch %s failed: %v", t.BaseDir, err)
+				continue
+			}
+			changed := make([]string, 0)
+			for name, mtime := range current {
+				if old, ok := last[name]; !ok || !old.Equal(mtime) {
+					changed = append(changed, name)
+				}
+			}
+			for name := range last {
+				if _, ok := current[name]; !ok {
+					changed = append(changed, name)
+				}
+			}
+			last = current
+			if len(changed) > 0 {
+				t.invalidate(changed)
+			}
+		}
+	}()
+	return func() { close(done) }, nil
+}
+
+// snapshot 记录 BaseDir 下全部文件的修改时间  key 是相对 BaseDir 的路径
+func (t *BuildTemplate) snapshot() (map[string]time.Time, error) {
+	root, err := t.root()
+	if err != nil {
+		return nil, err
+	}
+	mtimes := make(map[string]time.Time)
+	err = fs.WalkDir(root, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mtimes[name] = info.ModTime()
+		return nil
+	})
+	return mtimes, err
+}
+
+// invalidate 从缓存中删除变化的模版
+func (t *BuildTemplate) invalidate(names []string) {
+	t.wrMux.Lock()
+	defer t.wrMux.Unlock()
+	t.version++
+	for _, name := range names {
+		if t.isShared(name) {
+			// layout 和 partial 被全部页面引用  全部重新解析
+			t.shared = nil
+			t.cacheMap = nil
+			t.masters = nil
+			t.variants = nil
+			xlog.Infof("template %s changed, reload all templates", name)
+			return
+		}
+	}
+	for _, name := range names {
+		delete(t.cacheMap, name)
+		delete(t.masters, name)
+		for key := range t.variants {
+			if strings.HasPrefix(key, name+"\x00") {
+				delete(t.variants, key)
+			}
+		}
+		xlog.Infof("template %s changed, reload it", name)
+	}
+}
+
+// writeDebugPage 开发模式下把模版错误渲染成页面返回
+func writeDebugPage(w http.ResponseWriter, name string, err error) {
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>template error</title></head>
+<body style="font-family: monospace">
+<h2>template %s error</h2>
+<pre style="background: #fee; padding: 12px; white-space: pre-wrap">%s</pre>
+</body></html>
+`, html.EscapeString(name), html.EscapeString(err.Error()))
+}
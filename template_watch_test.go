@@ -0,0 +1,81 @@
+package xiawuyue
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{"index.html": `v1`})
+	tpl := &BuildTemplate{BaseDir: dir}
+	stop, err := tpl.Watch(10 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	render := func() string {
+		p, err := tpl.Get("index.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := &bytes.Buffer{}
+		p.Execute(buf, nil)
+		return buf.String()
+	}
+	if got := render(); got != "v1" {
+		t.Fatalf("got %q", got)
+	}
+
+	writeTemplateFiles(t, dir, map[string]string{"index.html": `v2`})
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "index.html"), future, future)
+	deadline := time.Now().Add(2 * time.Second)
+	for render() != "v2" {
+		if time.Now().After(deadline) {
+			t.Fatal("template was not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTemplateWatchWhileServing(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{"index.html": `v1`})
+	tpl := &BuildTemplate{BaseDir: dir}
+	if _, err := tpl.Get("index.html"); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			tpl.Get("index.html")
+		}
+	}()
+	stop, err := tpl.Watch(10 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	<-done
+}
+
+func TestTemplateDevModeErrorPage(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFiles(t, dir, map[string]string{"broken.html": `{{ .Data `})
+	tpl := &BuildTemplate{BaseDir: dir, DevMode: true}
+
+	w := httptest.NewRecorder()
+	tpl.WriterBuffer(w, "broken.html", ResponseXia{})
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "broken.html") {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -107,6 +107,10 @@ func (t *Trie) searchPath(parts []string, depth int, params interface{}) (*Trie,
 		if t.Path == "" {
 			return nil, errors.New("path is nil")
 		}
+		// 最后一段的参数也要写入  eg. /user/:id  /static/*filepath
+		if err := t.writeParams(parts, depth, params); err != nil {
+			return nil, err
+		}
 		return t, nil
 	}
 
@@ -183,7 +187,12 @@ func (t *Trie) writeParams(parts []string, depth int, params interface{}) error
 			}
 
 			if t.isFuzzy && strings.HasPrefix(t.Part, "*") {
-				(*params.(*map[string]string))["*"] = strings.Join(parts[depth-1:], "/")
+				// 没有名字的通配符用 * 作为 key
+				name := t.Part[1:]
+				if name == "" {
+					name = "*"
+				}
+				(*params.(*map[string]string))[name] = strings.Join(parts[depth-1:], "/")
 			}
 		default:
 			return errors.New("params is illegal")
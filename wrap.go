@@ -0,0 +1,74 @@
+package xiawuyue
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// mountMethods 挂载 http.Handler 的时候注册的方法
+var mountMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+type contextKey struct{}
+
+// WrapH converts a http.Handler to HandlerFunc
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		h.ServeHTTP(c.Writer, c.Req)
+	}
+}
+
+// WrapF converts a http.HandlerFunc to HandlerFunc
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		f(c.Writer, c.Req)
+	}
+}
+
+// WrapMiddleware converts a standard func(http.Handler) http.Handler middleware to HandlerFunc,
+// if the middleware doesn't call next the rest of the chain is aborted
+func WrapMiddleware(m func(http.Handler) http.Handler) HandlerFunc {
+	handler := m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context().Value(contextKey{}).(*Context)
+		// 中间件可能替换了 writer 和 request
+		c.Writer = w
+		c.Req = r
+		c.NextHandle()
+	}))
+	return func(c *Context) {
+		w, r := c.Writer, c.Req
+		index := c.index
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, c)))
+		if c.index == index {
+			c.Abort()
+		}
+		c.Writer = w
+		c.Req = r
+	}
+}
+
+// Mount mounts a http.Handler under prefix, for all methods,
+// the prefix is stripped from the request path, so another Xia can be mounted too
+func (group *RouterGroup) Mount(prefix string, h http.Handler) {
+	absolutePath := strings.TrimSuffix(path.Join(group.prefix, prefix), "/")
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" {
+			r.URL.Path = "/"
+		}
+		h.ServeHTTP(w, r)
+	})
+	handler := WrapH(http.StripPrefix(absolutePath, inner))
+	for _, method := range mountMethods {
+		group.addRouter(method, prefix, handler)
+		group.addRouter(method, path.Join(prefix, "/*filepath"), handler)
+	}
+}
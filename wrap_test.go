@@ -0,0 +1,77 @@
+package xiawuyue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	sub := New()
+	sub.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "sub index")
+	})
+	sub.GET("/hello", func(c *Context) {
+		c.String(http.StatusOK, "sub hello")
+	})
+
+	x := New()
+	x.Group("/apps").Mount("/sub", sub)
+	x.Mount("/std", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	}))
+	x.GET("/f", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("f"))
+	}))
+
+	cases := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/apps/sub", "sub index"},
+		{http.MethodGet, "/apps/sub/hello", "sub hello"},
+		{http.MethodPost, "/std/a/b", "POST /a/b"},
+		{http.MethodGet, "/f", "f"},
+	}
+	for _, cs := range cases {
+		w := httptest.NewRecorder()
+		x.ServeHTTP(w, httptest.NewRequest(cs.method, cs.path, nil))
+		if w.Body.String() != cs.want {
+			t.Errorf("%s %s got %q, want %q", cs.method, cs.path, w.Body.String(), cs.want)
+		}
+	}
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	x := New()
+	x.Use(WrapMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("X-Std", "ok")
+			next.ServeHTTP(w, r)
+		})
+	}))
+	called := false
+	x.GET("/secret", func(c *Context) {
+		called = true
+		c.String(http.StatusOK, "secret")
+	})
+
+	w := httptest.NewRecorder()
+	x.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/secret", nil))
+	if w.Code != http.StatusUnauthorized || called {
+		t.Fatalf("unauthorized request got %d, handler called %v", w.Code, called)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/secret", nil)
+	req.Header.Set("Authorization", "token")
+	w = httptest.NewRecorder()
+	x.ServeHTTP(w, req)
+	if w.Body.String() != "secret" || w.Header().Get("X-Std") != "ok" {
+		t.Fatalf("authorized request got %q, header %q", w.Body.String(), w.Header().Get("X-Std"))
+	}
+}
@@ -3,23 +3,74 @@ package xconfig
 import (
 	"strconv"
 	"sync"
+	"time"
 )
 
 // using function
 type WeConfig struct {
 	// sections
-	sections map[string]weSection
+	sections map[string]*weSection
+	// section 的顺序
+	order []string
 	// groutine scure
 	lock sync.Mutex
+
+	// 从文件加载时记录  Reload 使用
+	path     string
+	format   string
+	includes []string // ini include 的文件
+	// Save 使用  doc 是 ini 文件的每一行  baseline 是加载时的值
+	doc      []docLine
+	baseline map[string]string
+	// Reload 时的回调
+	subscribers []subscriber
+	reloadFuncs []func()
+	errorFuncs  []func(err error)
 }
 
 type weSection struct {
 	keyValue map[string]string
+	// key 的顺序  保存的时候使用
+	keys []string
+}
+
+func newSection() *weSection {
+	return &weSection{keyValue: make(map[string]string)}
+}
+
+// get 读取 key  section 不存在时也可以调用
+func (s *weSection) get(key string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	v, ok := s.keyValue[key]
+	return v, ok
+}
+
+func (s *weSection) set(key, value string) {
+	if _, ok := s.keyValue[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.keyValue[key] = value
+}
+
+func (s *weSection) delete(key string) {
+	if _, ok := s.keyValue[key]; !ok {
+		return
+	}
+	delete(s.keyValue, key)
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i:i], s.keys[i+1:]...)
+			break
+		}
+	}
 }
 
 // GetValueInt out put int type
 func (c *WeConfig) GetValueInt(section, key string, def int) int {
 	c.lock.Lock()
+	defer c.lock.Unlock()
 	if _, ok := c.sections[section]; !ok {
 		return def
 	}
@@ -28,7 +79,6 @@ func (c *WeConfig) GetValueInt(section, key string, def int) int {
 		return def
 	}
 	result := c.sections[section].keyValue[key]
-	c.lock.Unlock()
 	i, e := strconv.Atoi(result)
 	if e != nil {
 		return def
@@ -39,6 +89,7 @@ func (c *WeConfig) GetValueInt(section, key string, def int) int {
 // GetValue default get value, out put string
 func (c *WeConfig) GetValue(section, key, def string) string {
 	c.lock.Lock()
+	defer c.lock.Unlock()
 	if _, ok := c.sections[section]; !ok {
 		return def
 	}
@@ -47,12 +98,12 @@ func (c *WeConfig) GetValue(section, key, def string) string {
 		return def
 	}
 	result := c.sections[section].keyValue[key]
-	c.lock.Unlock()
 	return result
 }
 
 func (c *WeConfig) GetValueFloat64(section, key string, def float64) float64 {
 	c.lock.Lock()
+	defer c.lock.Unlock()
 	if _, ok := c.sections[section]; !ok {
 		return def
 	}
@@ -61,10 +112,91 @@ func (c *WeConfig) GetValueFloat64(section, key string, def float64) float64 {
 		return def
 	}
 	result := c.sections[section].keyValue[key]
-	c.lock.Unlock()
 	f, e := strconv.ParseFloat(result, 64)
 	if e != nil {
 		return def
 	}
 	return f
 }
+
+// GetSection returns a copy of the key values in section, nil if the section doesn't exist
+func (c *WeConfig) GetSection(section string) map[string]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s, ok := c.sections[section]
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string, len(s.keyValue))
+	for k, v := range s.keyValue {
+		result[k] = v
+	}
+	return result
+}
+
+// GetBool returns the bool value, true/false, 1/0, yes/no and on/off are supported
+func (c *WeConfig) GetBool(section, key string, def bool) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	v, ok := c.sections[section].get(key)
+	if !ok {
+		return def
+	}
+	b, err := parseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// GetDuration returns the value parsed by time.ParseDuration, eg. 1m30s
+func (c *WeConfig) GetDuration(section, key string, def time.Duration) time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	v, ok := c.sections[section].get(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
+// GetStrings splits the value by comma and trims the items, eg. a, b,c
+func (c *WeConfig) GetStrings(section, key string, def []string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	v, ok := c.sections[section].get(key)
+	if !ok {
+		return def
+	}
+	return splitList(v)
+}
+
+// HasKey reports whether key is in section
+func (c *WeConfig) HasKey(section, key string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.sections[section].get(key)
+	return ok
+}
+
+// Sections returns the names of the sections in order
+func (c *WeConfig) Sections() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string(nil), c.order...)
+}
+
+// Keys returns the keys of section in order, nil if the section doesn't exist
+func (c *WeConfig) Keys(section string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s, ok := c.sections[section]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), s.keys...)
+}
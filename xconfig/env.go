@@ -0,0 +1,64 @@
+package xconfig
+
+import (
+	"sort"
+	"strings"
+)
+
+// envName XIA_ + section + key  非字母数字的字符替换为 _ 并转大写
+func envName(s string) string {
+	b := []byte(strings.ToUpper(s))
+	for i, c := range b {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// applyEnv overrides the config with env, eg. XIA_PROJECT_LOGPATH=/data/log sets
+// logpath in [project]. The section must exist, the key is added if it doesn't.
+// XIA_DEFAULT_KEY sets the keys of DefaultSection.
+func applyEnv(sections map[string]*weSection, prefix string, env []string) {
+	if prefix == "" {
+		return
+	}
+	// 长的 section 优先匹配  eg. [db_replica] 和 [db]
+	names := make([]string, 0, len(sections)+1)
+	for name := range sections {
+		names = append(names, name)
+	}
+	if _, ok := sections[DefaultSection]; !ok {
+		names = append(names, DefaultSection)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, kv := range env {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		name = name[len(prefix):]
+		for _, section := range names {
+			rest, ok := strings.CutPrefix(name, envName(section)+"_")
+			if !ok || rest == "" {
+				continue
+			}
+			s, ok := sections[section]
+			if !ok {
+				s = newSection()
+				sections[section] = s
+			}
+			key := strings.ToLower(rest)
+			// 已有的 key 按转换后的名字匹配  eg. level.db => LEVEL_DB
+			for k := range s.keyValue {
+				if envName(k) == rest {
+					key = k
+					break
+				}
+			}
+			s.set(key, value)
+			break
+		}
+	}
+}
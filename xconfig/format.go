@@ -0,0 +1,123 @@
+package xconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// json/yaml/toml 都先解析成 map  再按照下面的规则转成 section
+//
+//	顶层的 map          => section
+//	顶层的其他值        => DefaultSection
+//	section 中嵌套的 map => key 用 . 连接  eg. [db] pool.size
+//	数组                => 元素用 , 连接  eg. hosts = a,b
+//	数组中的 map        => key 带上下标  eg. [servers] 0.host
+
+func flatten(root map[string]interface{}) map[string]*weSection {
+	sections := make(map[string]*weSection)
+	for name, v := range root {
+		if m, ok := v.(map[string]interface{}); ok {
+			s, ok := sections[name]
+			if !ok {
+				s = newSection()
+				sections[name] = s
+			}
+			flattenInto(s, "", m)
+			continue
+		}
+		if list, ok := v.([]interface{}); ok && hasMap(list) {
+			s := newSection()
+			sections[name] = s
+			for i, item := range list {
+				flattenValue(s, strconv.Itoa(i), item)
+			}
+			continue
+		}
+		s, ok := sections[DefaultSection]
+		if !ok {
+			s = newSection()
+			sections[DefaultSection] = s
+		}
+		flattenValue(s, name, v)
+	}
+	// map 没有顺序  按 key 排序
+	for _, s := range sections {
+		sort.Strings(s.keys)
+	}
+	return sections
+}
+
+func flattenInto(s *weSection, prefix string, m map[string]interface{}) {
+	for k, v := range m {
+		flattenValue(s, prefix+k, v)
+	}
+}
+
+func flattenValue(s *weSection, key string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		flattenInto(s, key+".", v)
+	case []interface{}:
+		if hasMap(v) {
+			for i, item := range v {
+				flattenValue(s, key+"."+strconv.Itoa(i), item)
+			}
+			return
+		}
+		s.set(key, scalarString(v))
+	default:
+		s.set(key, scalarString(v))
+	}
+}
+
+func hasMap(list []interface{}) bool {
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = scalarString(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func parseJSON(data []byte) (map[string]*weSection, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// 数字保持原样  不转成 float64
+	dec.UseNumber()
+	var root map[string]interface{}
+	if err := dec.Decode(&root); err != nil {
+		if e, ok := err.(*json.SyntaxError); ok {
+			return nil, fmt.Errorf("json: line %d: %w", lineOf(data, int(e.Offset)), err)
+		}
+		return nil, fmt.Errorf("json: %w", err)
+	}
+	return flatten(root), nil
+}
+
+// lineOf returns the line number of offset, starts from 1
+func lineOf(data []byte, offset int) int {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
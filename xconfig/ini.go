@@ -0,0 +1,337 @@
+package xconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ini 语法
+//
+//	# 和 ; 开头的行以及值后面空格开始的 # ; 是注释
+//	name = xia                 ; 第一个 section 之前的 key 属于 DefaultSection
+//	[project]
+//	logpath = ${HOME}/log/     ; ${ENV} 环境变量
+//	logfile = ${logpath}xia.log  ; ${key} 同一个 section 或 DefaultSection 中的 key
+//	dsn = ${db.user}@tcp(${db.host})  ; ${section.key}
+//	title = "a # b"            ; 引号中的 # 不是注释  "" 是空字符串
+//	raw = '${not_replaced}'    ; 单引号中的值不做替换
+//	escaped = $${HOME}         ; $${ 是 ${ 本身  找不到的 ${name} 也按原样保留
+//	hosts = a, \
+//	        b                  ; 行尾的 \ 连接下一行
+//	debug                      ; 没有 = 的 key 值为空
+//	include = db.ini           ; 相对当前文件的目录  被包含文件的 key 写入当前 section
+
+// maxIncludeDepth 防止 include 层数过多
+const maxIncludeDepth = 10
+
+type iniPos struct {
+	file string
+	line int
+}
+
+type iniParser struct {
+	sections map[string]*weSection
+	order    []string
+	// key 的位置  替换出错的时候报告行号
+	pos map[string]iniPos
+	// 单引号的值不做替换
+	literal map[string]bool
+	// 包含的文件  Watch 也会检查它们
+	files []string
+	stack []string
+	// 主文件的每一行  Save 的时候保留注释和顺序
+	doc []docLine
+}
+
+// docLine is a line of the main file, a key line keeps its continuation lines in raw
+type docLine struct {
+	raw     string
+	section string
+	key     string
+	header  bool
+}
+
+func refKey(section, key string) string {
+	return section + "\x00" + key
+}
+
+// parseINI parses r, name is the file used by the errors and the includes, it can be empty
+func parseINI(r io.Reader, name string) (*iniParser, error) {
+	p := &iniParser{
+		sections: make(map[string]*weSection),
+		pos:      make(map[string]iniPos),
+		literal:  make(map[string]bool),
+		doc:      make([]docLine, 0),
+	}
+	if name != "" {
+		p.stack = append(p.stack, name)
+	}
+	if err := p.parse(r, name, DefaultSection); err != nil {
+		return nil, err
+	}
+	if err := p.interpolate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *iniParser) errorf(file string, line int, format string, args ...interface{}) error {
+	if file == "" {
+		return fmt.Errorf("ini: line %d: %s", line, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf("ini: %s: line %d: %s", file, line, fmt.Sprintf(format, args...))
+}
+
+func (p *iniParser) section(name string) *weSection {
+	s, ok := p.sections[name]
+	if !ok {
+		s = newSection()
+		p.sections[name] = s
+		p.order = append(p.order, name)
+	}
+	return s
+}
+
+// parse 解析一个文件  section 是开始时所在的 section
+func (p *iniParser) parse(r io.Reader, file, section string) error {
+	// include 的文件不记录
+	main := len(p.stack) <= 1
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		start := lineNo
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if len(line) > 0 && (line[0] == '#' || line[0] == ';') {
+			if main {
+				p.doc = append(p.doc, docLine{raw: raw})
+			}
+			continue
+		}
+		// 行尾的 \ 连接下一行
+		for strings.HasSuffix(line, "\\") {
+			line = strings.TrimSpace(line[:len(line)-1])
+			if !scanner.Scan() {
+				break
+			}
+			lineNo++
+			raw += "\n" + scanner.Text()
+			line += " " + strings.TrimSpace(scanner.Text())
+		}
+		if len(line) < 1 {
+			if main {
+				p.doc = append(p.doc, docLine{raw: raw})
+			}
+			continue
+		}
+
+		if line[0] == '[' {
+			line = stripInlineComment(line)
+			if line[len(line)-1] != ']' {
+				return p.errorf(file, start, "section less '[' or ']'")
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return p.errorf(file, start, "empty section name")
+			}
+			p.section(section)
+			if main {
+				p.doc = append(p.doc, docLine{raw: raw, section: section, header: true})
+			}
+			continue
+		}
+
+		var key, value string
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			key, value = strings.TrimSpace(line[:i]), line[i+1:]
+		} else {
+			key = stripInlineComment(line)
+		}
+		if key == "" {
+			return p.errorf(file, start, "key is empty")
+		}
+		value, literal, err := unquote(strings.TrimSpace(stripInlineComment(value)))
+		if err != nil {
+			return p.errorf(file, start, "%v", err)
+		}
+
+		if key == "include" {
+			if main {
+				p.doc = append(p.doc, docLine{raw: raw})
+			}
+			if err = p.include(value, file, start, section); err != nil {
+				return err
+			}
+			continue
+		}
+		if main {
+			p.doc = append(p.doc, docLine{raw: raw, section: section, key: key})
+		}
+		p.section(section).set(key, value)
+		ref := refKey(section, key)
+		p.pos[ref] = iniPos{file: file, line: start}
+		p.literal[ref] = literal
+	}
+	return scanner.Err()
+}
+
+func (p *iniParser) include(path, file string, line int, section string) error {
+	if path == "" {
+		return p.errorf(file, line, "include needs a file")
+	}
+	if !filepath.IsAbs(path) && file != "" {
+		path = filepath.Join(filepath.Dir(file), path)
+	}
+	for _, f := range p.stack {
+		if f == path {
+			return p.errorf(file, line, "include cycle %s", strings.Join(append(p.stack, path), " -> "))
+		}
+	}
+	if len(p.stack) >= maxIncludeDepth {
+		return p.errorf(file, line, "too many nested includes")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return p.errorf(file, line, "include: %v", err)
+	}
+	defer f.Close()
+	p.files = append(p.files, path)
+	p.stack = append(p.stack, path)
+	defer func() { p.stack = p.stack[:len(p.stack)-1] }()
+	return p.parse(f, path, section)
+}
+
+// stripInlineComment 去掉引号外面空白之后的 # 和 ; 注释
+func stripInlineComment(s string) string {
+	if i := inlineComment(s); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return strings.TrimSpace(s)
+}
+
+// inlineComment returns the index of the comment outside quotes, -1 if there's none
+func inlineComment(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' || c == ';':
+			if i == 0 || s[i-1] == ' ' || s[i-1] == '\t' {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unquote 去掉两边成对的引号  单引号的值返回 literal
+func unquote(s string) (string, bool, error) {
+	if len(s) == 0 || (s[0] != '"' && s[0] != '\'') {
+		return s, false, nil
+	}
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", false, fmt.Errorf("unterminated quote %s", s)
+	}
+	return s[1 : len(s)-1], s[0] == '\'', nil
+}
+
+func (p *iniParser) interpolate() error {
+	done := make(map[string]bool)
+	for section, s := range p.sections {
+		for key := range s.keyValue {
+			if _, err := p.resolve(section, key, done, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve 替换 section.key 中的 ${} 并保存  done 记录已经替换过的 key
+func (p *iniParser) resolve(section, key string, done map[string]bool, stack []string) (string, error) {
+	ref := refKey(section, key)
+	value := p.sections[section].keyValue[key]
+	if done[ref] || p.literal[ref] || !strings.Contains(value, "${") {
+		return value, nil
+	}
+	pos := p.pos[ref]
+	for _, r := range stack {
+		if r == ref {
+			return "", p.errorf(pos.file, pos.line, "${} cycle at %s.%s", section, key)
+		}
+	}
+	stack = append(stack, ref)
+
+	var b strings.Builder
+	rest := value
+	for {
+		i := strings.Index(rest, "${")
+		if i < 0 {
+			b.WriteString(rest)
+			break
+		}
+		// $${ 转义为 ${
+		if i > 0 && rest[i-1] == '$' {
+			b.WriteString(rest[:i-1] + "${")
+			rest = rest[i+2:]
+			continue
+		}
+		end := strings.IndexByte(rest[i:], '}')
+		if end < 0 {
+			// 没有 } 的按原样保留  eg. 密码中的 ${
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:i])
+		name := strings.TrimSpace(rest[i+2 : i+end])
+		origin := rest[i : i+end+1]
+		rest = rest[i+end+1:]
+
+		refSection, refKey, ok := p.lookupVar(section, name)
+		if ok {
+			v, err := p.resolve(refSection, refKey, done, stack)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			continue
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			b.WriteString(v)
+			continue
+		}
+		// 找不到的引用按原样保留  兼容之前包含 ${ 的值
+		b.WriteString(origin)
+	}
+	p.sections[section].keyValue[key] = b.String()
+	done[ref] = true
+	return b.String(), nil
+}
+
+// lookupVar finds the key referenced by name: section.key, a key of section or DefaultSection
+func (p *iniParser) lookupVar(section, name string) (string, string, bool) {
+	// section 和 key 中都可能有 .  从后往前尝试
+	for i := strings.LastIndexByte(name, '.'); i > 0; i = strings.LastIndexByte(name[:i], '.') {
+		if _, ok := p.sections[name[:i]].get(name[i+1:]); ok {
+			return name[:i], name[i+1:], true
+		}
+	}
+	if _, ok := p.sections[section].get(name); ok {
+		return section, name, true
+	}
+	if _, ok := p.sections[DefaultSection].get(name); ok {
+		return DefaultSection, name, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,90 @@
+package xconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestINISyntax(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XIA_TEST_HOME", "/home/xia")
+	files := map[string]string{
+		"app.ini": `name = xia   # global key
+[project]
+logpath = ${XIA_TEST_HOME}/log/
+logfile = ${logpath}${name}.log
+title = "a # b" ; comment
+empty = ""
+raw = '${not_replaced}'
+hosts = a, \
+        b, \
+        c
+debug
+host:port = 1
+password = p${a}b$${HOME}c${d
+
+[db]
+include = db.ini
+dsn = ${user}@tcp(${db.replica.host})
+[db.replica]
+host = 127.0.0.2
+`,
+		"db.ini": "user = root\n[cache]\nsize = 10\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf, err := LoadConfig(filepath.Join(dir, "app.ini"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, conf, map[string]string{
+		"default/name":      "xia",
+		"project/logpath":   "/home/xia/log/",
+		"project/logfile":   "/home/xia/log/xia.log",
+		"project/title":     "a # b",
+		"project/empty":     "",
+		"project/raw":       "${not_replaced}",
+		"project/hosts":     "a, b, c",
+		"project/debug":     "",
+		"project/host:port": "1",
+		"project/password":  "p${a}b${HOME}c${d",
+		"db/user":           "root",
+		"db/dsn":            "root@tcp(127.0.0.2)",
+		"cache/size":        "10",
+		"db.replica/host":   "127.0.0.2",
+		"project/not_exist": "<nil>",
+	})
+	if len(conf.includes) != 1 || !strings.HasSuffix(conf.includes[0], "db.ini") {
+		t.Errorf("got includes %v", conf.includes)
+	}
+}
+
+func TestINIErrors(t *testing.T) {
+	dir := t.TempDir()
+	cycle := filepath.Join(dir, "a.ini")
+	os.WriteFile(cycle, []byte("[a]\ninclude = a.ini\n"), 0644)
+
+	cases := []struct {
+		data, err string
+	}{
+		{"[a]\nb = 1\n[c\n", "line 3: section less"},
+		{"[a]\n= 1\n", "line 2: key is empty"},
+		{"[a]\nb = \"abc\n", "line 2: unterminated quote"},
+		{"[a]\nb = ${c}\nc = ${b}\n", "cycle"},
+		{"[a]\ninclude = not_exist.ini\n", "line 2: include"},
+	}
+	for _, c := range cases {
+		_, err := LoadConfig(strings.NewReader(c.data))
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%q: got %v, want %s", c.data, err, c.err)
+		}
+	}
+	if _, err := LoadConfig(cycle); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -1,79 +1,114 @@
 package xconfig
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 )
 
-// load file
+// DefaultSection holds the keys which don't belong to any section,
+// eg. the top level keys of json/yaml/toml
+const DefaultSection = "default"
 
-func LoadConfig(source interface{}) (*WeConfig, error) {
-	switch source.(type) {
+// EnvPrefix is the prefix of the environment variables overriding the config,
+// eg. XIA_PROJECT_LOGPATH overrides logpath in [project], empty disables the overrides
+var EnvPrefix = "XIA_"
+
+// LoadConfig loads the config from a file path, the format is detected by the extension
+// .ini/.conf (default), .json, .yaml/.yml and .toml, or from an io.Reader / []byte with
+// format, ini by default. The environment variables with EnvPrefix take precedence.
+//
+//	conf, err := xconfig.LoadConfig("conf/app.toml")
+//	conf, err := xconfig.LoadConfig(strings.NewReader(data), "json")
+func LoadConfig(source interface{}, format ...string) (*WeConfig, error) {
+	var f string
+	if len(format) > 0 {
+		f = format[0]
+	}
+	switch s := source.(type) {
 	case string:
-		return load(source.(string))
+		data, err := os.ReadFile(s)
+		if err != nil {
+			return nil, err
+		}
+		if f == "" {
+			f = formatByExt(s)
+		}
+		conf, err := loadData(data, f, s)
+		if err != nil {
+			return nil, err
+		}
+		conf.path, conf.format = s, f
+		return conf, nil
+	case []byte:
+		return loadData(s, f, "")
+	case io.Reader:
+		data, err := io.ReadAll(s)
+		if err != nil {
+			return nil, err
+		}
+		return loadData(data, f, "")
+	default:
+		return nil, fmt.Errorf("please input the correct file path or io.Reader, got %T", source)
+	}
+}
+
+func formatByExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
 	default:
-		return nil, fmt.Errorf("please input the correct file path, must input string type")
+		return "ini"
 	}
 }
 
-func load(path string) (*WeConfig, error) {
-	var file *os.File
+// loadData parses data, path is the file of data used by the includes of ini, it can be empty
+func loadData(data []byte, format, path string) (*WeConfig, error) {
+	var sections map[string]*weSection
+	var ini *iniParser
 	var err error
-	// section flag rule: must first match [section] then do other logic
-	var sectionFlag = true
-	var tempSection string
-	file, err = os.OpenFile(path, os.O_RDONLY, 0644)
+	switch strings.ToLower(format) {
+	case "", "ini", "conf":
+		if ini, err = parseINI(bytes.NewReader(data), path); err == nil {
+			sections = ini.sections
+		}
+	case "json":
+		sections, err = parseJSON(data)
+	case "yaml", "yml":
+		sections, err = parseYAML(data)
+	case "toml":
+		sections, err = parseTOML(data)
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
 	if err != nil {
 		return nil, err
 	}
-	theSections := make(map[string]weSection)
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if len(line) < 1 {
-			continue
-		}
-		if line[0] == '#' || line[0] == ';' {
-			continue
-		}
-		// skip before match first section
-		if sectionFlag && line[0] != '[' {
-			continue
+	conf := &WeConfig{sections: sections}
+	if ini != nil {
+		conf.order, conf.includes, conf.doc = ini.order, ini.files, ini.doc
+	} else {
+		for name := range sections {
+			conf.order = append(conf.order, name)
 		}
-
-		if line[0] == '[' {
-			if line[len(line)-1] != ']' {
-				return nil, fmt.Errorf("config file error, section less '[' or ']'")
-			}
-			sectionFlag = false
-			theSections[line[1:len(line)-1]] = weSection{make(map[string]string)}
-			tempSection = line[1 : len(line)-1]
-			continue
-		}
-
-		if _, ok := theSections[tempSection]; ok {
-			eqindex := strings.Index(line, "=")
-			if eqindex == -1 {
-				return nil, fmt.Errorf("config file error, key value need use '='!")
-			}
-			key := strings.TrimSpace(line[0:eqindex])
-			value := strings.TrimSpace(line[eqindex+1:])
-			if len(value) > 2 {
-				if value[0] == '"' || value[0] == '\'' {
-					value = value[1:]
-				}
-				l := len(value)
-				if value[l-1] == '"' || value[l-1] == '\'' {
-					value = value[:l-1]
-				}
-			}
-
-			theSections[tempSection].keyValue[key] = strings.TrimSpace(value)
+		sort.Strings(conf.order)
+	}
+	applyEnv(sections, EnvPrefix, os.Environ())
+	// 环境变量新增的 section 放在最后
+	for name := range sections {
+		if !slices.Contains(conf.order, name) {
+			conf.order = append(conf.order, name)
 		}
 	}
-	return &WeConfig{
-		sections: theSections,
-	}, nil
+	conf.baseline = conf.snapshotValues()
+	return conf, nil
 }
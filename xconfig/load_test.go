@@ -0,0 +1,137 @@
+package xconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func checkValues(t *testing.T, conf *WeConfig, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		section, key, _ := strings.Cut(k, "/")
+		if got := conf.GetValue(section, key, "<nil>"); got != v {
+			t.Errorf("[%s] %s = %q, want %q", section, key, got, v)
+		}
+	}
+}
+
+var formatWant = map[string]string{
+	"default/name":      "xia",
+	"project/logpath":   "/data/log/",
+	"project/port":      "8080",
+	"project/debug":     "true",
+	"db/hosts":          "a:3306,b:3306",
+	"db/pool.size":      "10",
+	"db/pool.idle":      "1m30s",
+	"servers/0.host":    "s1",
+	"servers/1.host":    "s2",
+	"project/multiline": "line1\nline2\n",
+}
+
+func TestLoadFormats(t *testing.T) {
+	cases := map[string]string{
+		"app.json": `{
+  "name": "xia",
+  "project": {"logpath": "/data/log/", "port": 8080, "debug": true, "multiline": "line1\nline2\n"},
+  "db": {"hosts": ["a:3306", "b:3306"], "pool": {"size": 10, "idle": "1m30s"}},
+  "servers": [{"host": "s1"}, {"host": "s2"}]
+}`,
+		"app.yaml": `# comment
+name: xia
+project:
+  logpath: "/data/log/"   # inline comment
+  port: 8080
+  debug: true
+  multiline: |
+    line1
+    line2
+db:
+  hosts: [a:3306, 'b:3306']
+  pool:
+    size: 10
+    idle: 1m30s
+servers:
+- host: s1
+- host: s2
+`,
+		"app.toml": `name = "xia"
+
+[project]
+logpath = '/data/log/'
+port = 8_080
+debug = true # inline comment
+multiline = """
+line1
+line2
+"""
+
+[db]
+hosts = [
+  "a:3306",
+  "b:3306", # trailing comma
+]
+pool = { size = 10, idle = "1m30s" }
+
+[[servers]]
+host = "s1"
+
+[[servers]]
+host = "s2"
+`,
+	}
+	dir := t.TempDir()
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			conf, err := LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkValues(t, conf, formatWant)
+		})
+	}
+}
+
+func TestLoadReaderAndEnv(t *testing.T) {
+	t.Setenv("XIA_PROJECT_LOGPATH", "/tmp/")
+	t.Setenv("XIA_PROJECT_LOG_MAX_SIZE", "5")
+	t.Setenv("XIA_LOG_LEVEL_DB", "debug")
+	t.Setenv("XIA_UNKNOWN_KEY", "x")
+
+	conf, err := LoadConfig(strings.NewReader("[project]\nlogpath = /data/log/\n[log]\nlevel.db = info\n"), "ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, conf, map[string]string{
+		"project/logpath":      "/tmp/",
+		"project/log_max_size": "5",
+		"log/level.db":         "debug",
+		"unknown/key":          "<nil>",
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	cases := []struct {
+		format, data, err string
+	}{
+		{"json", "{\n\"a\": 1,\n}", "line 3"},
+		{"yaml", "a:\n  b: 1\n   c: 2\n", "line 3"},
+		{"yaml", "a: 1\nb\n", "line 2"},
+		{"toml", "[a]\nb = 1\nc = \n", "line 3"},
+		{"toml", "[a]\n[a]\n", "line 2"},
+		{"toml", "a = []\n[a.b]\n", "line 2: a is not a table"},
+		{"toml", "a = [1]\n[[a]]\n", "line 2: a is not an array of tables"},
+		{"xml", "", "unknown config format"},
+	}
+	for _, c := range cases {
+		_, err := LoadConfig([]byte(c.data), c.format)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s %q: got %v, want %s", c.format, c.data, err, c.err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package xconfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Set sets the value of key in section, the section is created if it doesn't exist
+func (c *WeConfig) Set(section, key, value string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.sections == nil {
+		c.sections = make(map[string]*weSection)
+	}
+	s, ok := c.sections[section]
+	if !ok {
+		s = newSection()
+		c.sections[section] = s
+		c.order = append(c.order, section)
+	}
+	s.set(key, value)
+}
+
+// Delete deletes key from section
+func (c *WeConfig) Delete(section, key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if s, ok := c.sections[section]; ok {
+		s.delete(key)
+	}
+}
+
+// DeleteSection deletes section and all its keys
+func (c *WeConfig) DeleteSection(section string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.sections[section]; !ok {
+		return
+	}
+	delete(c.sections, section)
+	for i, name := range c.order {
+		if name == section {
+			c.order = append(c.order[:i:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// snapshotValues 记录每个 key 的值  Save 只改写变化过的 key
+func (c *WeConfig) snapshotValues() map[string]string {
+	values := make(map[string]string)
+	for section, s := range c.sections {
+		for key, v := range s.keyValue {
+			values[refKey(section, key)] = v
+		}
+	}
+	return values
+}
+
+// Save writes the config to path in ini format. For a config loaded from an ini file
+// the comments, the order and the unchanged lines are kept, the changed keys are
+// rewritten, the deleted keys and sections are removed, the new keys are added after
+// the last key of their section and the new sections at the end.
+// The values loaded from includes and environment variables are not written unless changed.
+func (c *WeConfig) Save(path string) error {
+	c.lock.Lock()
+	data := c.encode()
+	c.lock.Unlock()
+
+	// 先写临时文件再 rename  避免写了一半的配置被读到
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	} else {
+		os.Chmod(tmp.Name(), 0644)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (c *WeConfig) encode() []byte {
+	var b bytes.Buffer
+	// 不是从 ini 加载的配置全部写入
+	baseline := c.baseline
+	if c.doc == nil {
+		baseline = nil
+	}
+	// doc 中出现过的 key
+	inDoc := make(map[string]bool)
+	// 每个 section 最后一个 key 或者 header 的位置  新的 key 写在它后面
+	last := make(map[string]int)
+	for i, line := range c.doc {
+		if line.key != "" {
+			inDoc[refKey(line.section, line.key)] = true
+		}
+		if line.key != "" || line.header {
+			last[line.section] = i
+		}
+	}
+	// 需要追加的 key  新增的或者来自 include 和环境变量并且修改过的
+	added := func(section string) []string {
+		s := c.sections[section]
+		if s == nil {
+			return nil
+		}
+		var keys []string
+		for _, key := range s.keys {
+			ref := refKey(section, key)
+			if inDoc[ref] {
+				continue
+			}
+			if old, ok := baseline[ref]; ok && old == s.keyValue[key] {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		return keys
+	}
+	writeKeys := func(section string, keys []string) {
+		for _, key := range keys {
+			b.WriteString(formatKeyValue(key, c.sections[section].keyValue[key], ""))
+		}
+	}
+
+	if _, ok := last[DefaultSection]; !ok {
+		writeKeys(DefaultSection, added(DefaultSection))
+	}
+	written := make(map[string]bool)
+	skip := false
+	for i, line := range c.doc {
+		switch {
+		case line.header:
+			_, ok := c.sections[line.section]
+			skip = !ok
+			if !skip {
+				b.WriteString(line.raw + "\n")
+			}
+		case skip:
+		case line.key != "":
+			s := c.sections[line.section]
+			value, ok := s.get(line.key)
+			if !ok {
+				// 删除的 key
+				break
+			}
+			if old, ok := baseline[refKey(line.section, line.key)]; ok && old == value {
+				b.WriteString(line.raw + "\n")
+			} else {
+				b.WriteString(formatKeyValue(line.key, value, trailingComment(line.raw)))
+			}
+		default:
+			b.WriteString(line.raw + "\n")
+		}
+		if i == last[line.section] && !skip && !written[line.section] {
+			written[line.section] = true
+			writeKeys(line.section, added(line.section))
+		}
+	}
+
+	for _, section := range c.order {
+		if _, ok := last[section]; ok || section == DefaultSection {
+			continue
+		}
+		keys := added(section)
+		if len(keys) == 0 && hasSection(baseline, section) {
+			// 来自 include 和环境变量的 section
+			continue
+		}
+		if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n\n")) {
+			b.WriteByte('\n')
+		}
+		b.WriteString("[" + section + "]\n")
+		writeKeys(section, keys)
+	}
+	return b.Bytes()
+}
+
+func hasSection(baseline map[string]string, section string) bool {
+	prefix := refKey(section, "")
+	for ref := range baseline {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// trailingComment returns the inline comment of a key line with the spaces before it,
+// eg. "   # inline", the comment of the last line is used for continuation lines
+func trailingComment(raw string) string {
+	line := raw[strings.LastIndexByte(raw, '\n')+1:]
+	start := 0
+	if !strings.Contains(raw, "\n") {
+		// 第一行从 = 后面开始找  key 中的 # 不是注释
+		start = strings.IndexByte(line, '=') + 1
+	}
+	i := inlineComment(line[start:])
+	if i < 0 {
+		return ""
+	}
+	i += start
+	gap := len(line[:i]) - len(strings.TrimRight(line[:i], " \t"))
+	if gap == i {
+		// 整行都是注释的续行
+		return ""
+	}
+	return line[i-gap:]
+}
+
+// formatKeyValue 需要的时候给值加上引号  单引号的值读取时不做 ${} 替换，comment 是保留的行尾注释
+// 同时有 ' 和 ${ 的值只能用双引号  ${ 写成 $${ 转义
+func formatKeyValue(key, value, comment string) string {
+	if value == "" || value != strings.TrimSpace(value) || strings.ContainsAny(value[:1], `"'#;`) ||
+		strings.Contains(value, "${") || strings.Contains(value, " #") || strings.Contains(value, " ;") ||
+		strings.Contains(value, "\t#") || strings.Contains(value, "\t;") || strings.HasSuffix(value, "\\") {
+		if !strings.Contains(value, "'") {
+			value = "'" + value + "'"
+		} else {
+			value = `"` + strings.ReplaceAll(value, "${", "$${") + `"`
+		}
+	}
+	return key + " = " + value + comment + "\n"
+}
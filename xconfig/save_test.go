@@ -0,0 +1,177 @@
+package xconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetters(t *testing.T) {
+	conf, err := LoadConfig(strings.NewReader("[b]\nz = 1\ndebug = yes\ntimeout = 1m30s\nhosts = a, b,,c\n[a]\nx = 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conf.Sections(); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("got sections %v", got)
+	}
+	if got := conf.Keys("b"); !reflect.DeepEqual(got, []string{"z", "debug", "timeout", "hosts"}) {
+		t.Errorf("got keys %v", got)
+	}
+	if !conf.GetBool("b", "debug", false) || conf.GetBool("b", "z", false) != true || conf.GetBool("b", "hosts", true) != true {
+		t.Error("GetBool")
+	}
+	if conf.GetDuration("b", "timeout", 0) != 90*time.Second || conf.GetDuration("b", "z", time.Second) != time.Second {
+		t.Error("GetDuration")
+	}
+	if got := conf.GetStrings("b", "hosts", nil); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("got %v", got)
+	}
+	if !conf.HasKey("a", "x") || conf.HasKey("a", "y") || conf.HasKey("c", "x") {
+		t.Error("HasKey")
+	}
+
+	conf.Set("c", "k", "v")
+	conf.Delete("b", "z")
+	conf.DeleteSection("a")
+	if got := conf.Sections(); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("got sections %v", got)
+	}
+	if conf.HasKey("b", "z") || conf.GetValue("c", "k", "") != "v" || conf.Keys("a") != nil {
+		t.Error("Set/Delete")
+	}
+}
+
+func TestSaveKeepsComments(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XIA_PROJECT_PORT", "9090")
+	path := filepath.Join(dir, "app.ini")
+	os.WriteFile(filepath.Join(dir, "db.ini"), []byte("user = root\n"), 0644)
+	os.WriteFile(path, []byte(`# app config
+name = xia
+
+[project]
+; log dir
+logpath = /data/log/   # inline
+logfile = ${logpath}xia.log
+port = 8080 ; http port
+hosts = a, \
+    b
+debug  # bare key
+
+[db]
+include = db.ini
+host = 127.0.0.1
+
+[old]
+# removed with the section
+key = 1
+`), 0600)
+	conf, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Set("project", "logpath", "/tmp/log dir/")
+	conf.Set("project", "title", "a # b")
+	conf.Set("project", "port", "8081")
+	conf.Set("project", "debug", "true")
+	conf.Delete("project", "hosts")
+	conf.Set("db", "user", "admin")
+	conf.DeleteSection("old")
+	conf.Set("new", "raw", "${x}")
+	conf.Set(DefaultSection, "mode", "")
+	if err = conf.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := `# app config
+name = xia
+mode = ''
+
+[project]
+; log dir
+logpath = /tmp/log dir/   # inline
+logfile = ${logpath}xia.log
+port = 8081 ; http port
+debug = true  # bare key
+title = 'a # b'
+
+[db]
+include = db.ini
+host = 127.0.0.1
+user = admin
+
+[new]
+raw = '${x}'
+`
+	if string(data) != want {
+		t.Errorf("got\n%s\nwant\n%s", data, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode changed to %v", info.Mode())
+	}
+
+	// 保存之后重新加载  值保持一致
+	reloaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, reloaded, map[string]string{
+		"project/logpath": "/tmp/log dir/",
+		"project/logfile": "/tmp/log dir/xia.log",
+		"project/title":   "a # b",
+		"project/port":    "9090",
+		"db/user":         "admin",
+		"new/raw":         "${x}",
+		"default/mode":    "",
+	})
+}
+
+func TestSaveJSONAsINI(t *testing.T) {
+	conf, err := LoadConfig([]byte(`{"name": "xia", "db": {"port": 3306, "host": "h"}}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "app.ini")
+	if err = conf.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "name = xia\n\n[db]\nhost = h\nport = 3306\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
+
+func TestSaveQuotesValues(t *testing.T) {
+	conf, err := LoadConfig([]byte(""), "ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]string{
+		"color": "#fff",
+		"sep":   ";",
+		"home":  "it's ${HOME}",
+		"raw":   "${HOME}",
+		"quote": "it's",
+	}
+	for key, value := range values {
+		conf.Set("a", key, value)
+	}
+	path := filepath.Join(t.TempDir(), "app.ini")
+	if err = conf.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	loaded, err := LoadConfig(data, "ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range values {
+		if got := loaded.GetValue("a", key, ""); got != value {
+			t.Errorf("%s: got %q, want %q\n%s", key, got, value, data)
+		}
+	}
+}
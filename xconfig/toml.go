@@ -0,0 +1,444 @@
+package xconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// toml 支持 table、[[array of tables]]、dotted key、inline table、数组和四种字符串，
+// 数字、布尔和时间都按原文保存，数字中的 _ 会去掉
+
+// tableArray 是 [[name]] 定义的 array of tables  和普通的数组区分开
+type tableArray []interface{}
+
+type tomlParser struct {
+	s    string
+	pos  int
+	line int
+	root map[string]interface{}
+	// 当前 table
+	cur map[string]interface{}
+	// 由 [table] 定义过的 table  不能重复定义
+	defined map[string]bool
+}
+
+func parseTOML(data []byte) (map[string]*weSection, error) {
+	p := &tomlParser{
+		s:       string(data),
+		line:    1,
+		root:    make(map[string]interface{}),
+		defined: make(map[string]bool),
+	}
+	p.cur = p.root
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return flatten(normalizeTOML(p.root).(map[string]interface{})), nil
+}
+
+// normalizeTOML 把 tableArray 转成 flatten 使用的 []interface{}
+func normalizeTOML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalizeTOML(item)
+		}
+		return v
+	case tableArray:
+		list := []interface{}(v)
+		for i, item := range list {
+			list[i] = normalizeTOML(item)
+		}
+		return list
+	}
+	return v
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("toml: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipSpace(true)
+		if p.pos >= len(p.s) {
+			return nil
+		}
+		var err error
+		if p.s[p.pos] == '[' {
+			err = p.parseTable()
+		} else {
+			err = p.parseKeyValue(p.cur)
+		}
+		if err != nil {
+			return err
+		}
+		if err = p.endOfLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipSpace skips spaces and comments, newlines too if multiline
+func (p *tomlParser) skipSpace(multiline bool) {
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && multiline:
+			p.pos++
+			p.line++
+		case c == '#':
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace(false)
+	if p.pos < len(p.s) && p.s[p.pos] != '\n' {
+		return p.errorf("expect newline, got %q", p.s[p.pos])
+	}
+	return nil
+}
+
+func (p *tomlParser) parseTable() error {
+	array := strings.HasPrefix(p.s[p.pos:], "[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpace(false)
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace(false)
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.s[p.pos:], closing) {
+		return p.errorf("expect %s", closing)
+	}
+	p.pos += len(closing)
+
+	parent, err := p.table(p.root, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if array {
+		list, ok := parent[last].(tableArray)
+		if !ok && parent[last] != nil {
+			return p.errorf("%s is not an array of tables", strings.Join(keys, "."))
+		}
+		t := make(map[string]interface{})
+		parent[last] = append(list, t)
+		p.cur = t
+		return nil
+	}
+	name := strings.Join(keys, "\x00")
+	if p.defined[name] {
+		return p.errorf("table %s is defined twice", strings.Join(keys, "."))
+	}
+	p.defined[name] = true
+	p.cur, err = p.table(parent, keys[len(keys)-1:])
+	return err
+}
+
+// table returns the table of keys under t, the tables are created if they don't exist
+func (p *tomlParser) table(t map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		switch v := t[key].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			t[key] = next
+			t = next
+		case map[string]interface{}:
+			t = v
+		case tableArray:
+			// array of tables 取最后一个
+			if len(v) == 0 {
+				return nil, p.errorf("%s is not a table", key)
+			}
+			last, ok := v[len(v)-1].(map[string]interface{})
+			if !ok {
+				return nil, p.errorf("%s is not a table", key)
+			}
+			t = last
+		default:
+			return nil, p.errorf("%s is not a table", key)
+		}
+	}
+	return t, nil
+}
+
+func (p *tomlParser) parseKeyValue(t map[string]interface{}) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace(false)
+	if p.pos >= len(p.s) || p.s[p.pos] != '=' {
+		return p.errorf("expect = after key %s", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace(false)
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	t, err = p.table(t, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, ok := t[last]; ok {
+		return p.errorf("duplicate key %s", strings.Join(keys, "."))
+	}
+	t[last] = value
+	return nil
+}
+
+// parseKey parses bare, quoted and dotted keys
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace(false)
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("expect key")
+		}
+		switch c := p.s[p.pos]; {
+		case c == '"' || c == '\'':
+			key, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		default:
+			start := p.pos
+			for p.pos < len(p.s) && isTOMLBareKey(p.s[p.pos]) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("invalid key character %q", c)
+			}
+			keys = append(keys, p.s[start:p.pos])
+		}
+		p.skipSpace(false)
+		if p.pos >= len(p.s) || p.s[p.pos] != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isTOMLBareKey(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.pos >= len(p.s) {
+		return nil, p.errorf("expect value")
+	}
+	switch p.s[p.pos] {
+	case '"', '\'':
+		return p.parseString()
+	case '[':
+		return p.parseArray()
+	case '{':
+		return p.parseInlineTable()
+	}
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n,]}#", p.s[p.pos]) < 0 {
+		p.pos++
+	}
+	// 日期和时间之间可以用空格分隔  eg. 1979-05-27 07:32:00
+	if p.pos+1 < len(p.s) && p.s[p.pos] == ' ' && p.pos-start == 10 && p.s[start+4] == '-' &&
+		p.s[p.pos+1] >= '0' && p.s[p.pos+1] <= '9' {
+		p.pos++
+		for p.pos < len(p.s) && strings.IndexByte(" \t\r\n,]}#", p.s[p.pos]) < 0 {
+			p.pos++
+		}
+	}
+	v := p.s[start:p.pos]
+	switch {
+	case v == "":
+		return nil, p.errorf("expect value")
+	case v == "true" || v == "false":
+		return v, nil
+	case v[0] == '+' || v[0] == '-' || v[0] >= '0' && v[0] <= '9' || v == "inf" || v == "nan":
+		if !strings.Contains(v, "-") || v[0] == '-' || strings.ContainsAny(v, "eE") {
+			return strings.ReplaceAll(v, "_", ""), nil
+		}
+		// 时间
+		return v, nil
+	}
+	return nil, p.errorf("invalid value %s", v)
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+	p.pos++
+	list := make([]interface{}, 0)
+	for {
+		p.skipSpace(true)
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("missing ]")
+		}
+		if p.s[p.pos] == ']' {
+			p.pos++
+			return list, nil
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+		p.skipSpace(true)
+		if p.pos < len(p.s) && p.s[p.pos] == ',' {
+			p.pos++
+		} else if p.pos < len(p.s) && p.s[p.pos] != ']' {
+			return nil, p.errorf("expect , or ] in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	p.pos++
+	t := make(map[string]interface{})
+	p.skipSpace(false)
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return t, nil
+	}
+	for {
+		if err := p.parseKeyValue(t); err != nil {
+			return nil, err
+		}
+		p.skipSpace(false)
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("missing }")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return t, nil
+		default:
+			return nil, p.errorf("expect , or } in inline table")
+		}
+	}
+}
+
+func (p *tomlParser) parseString() (string, error) {
+	quote := p.s[p.pos]
+	multi := strings.HasPrefix(p.s[p.pos:], strings.Repeat(string(quote), 3))
+	if multi {
+		p.pos += 3
+		// 紧跟的换行会被去掉
+		if strings.HasPrefix(p.s[p.pos:], "\r\n") {
+			p.pos += 2
+			p.line++
+		} else if p.pos < len(p.s) && p.s[p.pos] == '\n' {
+			p.pos++
+			p.line++
+		}
+	} else {
+		p.pos++
+	}
+
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case multi && strings.HasPrefix(p.s[p.pos:], strings.Repeat(string(quote), 3)):
+			p.pos += 3
+			// """ 结尾可以多出最多两个引号
+			for i := 0; i < 2 && p.pos < len(p.s) && p.s[p.pos] == quote; i++ {
+				b.WriteByte(quote)
+				p.pos++
+			}
+			return b.String(), nil
+		case !multi && c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\n':
+			if !multi {
+				return "", p.errorf("newline in string")
+			}
+			p.line++
+			b.WriteByte(c)
+			p.pos++
+		case c == '\\' && quote == '"':
+			if err := p.parseEscape(&b, multi); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder, multi bool) error {
+	p.pos++
+	if p.pos >= len(p.s) {
+		return p.errorf("unterminated string")
+	}
+	c := p.s[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"', '\\':
+		b.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.s) {
+			return p.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.s[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid unicode escape")
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		// 行尾的 \ 去掉换行和下一行开头的空白
+		if multi && (c == '\n' || c == ' ' || c == '\t' || c == '\r') {
+			p.pos--
+			for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+				if p.s[p.pos] == '\n' {
+					p.line++
+				}
+				p.pos++
+			}
+			return nil
+		}
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
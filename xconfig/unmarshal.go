@@ -0,0 +1,260 @@
+package xconfig
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Unmarshal fills the struct v, the struct fields are sections and the other fields
+// are the keys of DefaultSection, see UnmarshalSection for the tags
+//
+//	type Config struct {
+//		Project ProjectConfig `ini:"project"`
+//		DB      DBConfig      `ini:"db"`
+//	}
+func (c *WeConfig) Unmarshal(v interface{}) error {
+	elem, err := structElem(v)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	d := &decoder{sections: c.sections}
+	t := elem.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, ok := fieldName(t.Field(i))
+		if !ok {
+			continue
+		}
+		f := elem.Field(i)
+		if isSection(f.Type()) {
+			d.decodeStruct(f, field, "")
+			continue
+		}
+		d.decodeField(f, t.Field(i), DefaultSection, "", field)
+	}
+	return d.err()
+}
+
+// UnmarshalSection fills the struct v with the keys of section
+//
+//	type DBConfig struct {
+//		Host    string        `ini:"host" required:"true"`
+//		Port    int           `ini:"port" default:"3306"`
+//		Timeout time.Duration `ini:"timeout" default:"5s"`
+//		Debug   bool          `ini:"debug"`
+//		Hosts   []string      `ini:"hosts"`    // a,b,c
+//		Replica ReplicaConfig `ini:"replica"`  // replica.host 或者 [db.replica] 中的 host
+//		Ignored string        `ini:"-"`
+//	}
+//
+// the key is the lower case field name without the ini tag, the error lists
+// all the missing required keys and the values which can't be parsed
+func (c *WeConfig) UnmarshalSection(section string, v interface{}) error {
+	elem, err := structElem(v)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	d := &decoder{sections: c.sections}
+	d.decodeStruct(elem, section, "")
+	return d.err()
+}
+
+func structElem(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return reflect.Value{}, errors.New("xconfig: unmarshal needs a non nil struct pointer")
+	}
+	elem := rv.Elem()
+	if elem.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("xconfig: unmarshal needs a struct pointer, got %T", v)
+	}
+	return elem, nil
+}
+
+// fieldName returns the key of the field, false if the field is skipped
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name := field.Tag.Get("ini")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, true
+}
+
+// isSection 结构体作为 section  实现了 TextUnmarshaler 的结构体作为普通的值
+func isSection(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+type decoder struct {
+	sections map[string]*weSection
+	missing  []string
+	errs     []error
+}
+
+func (d *decoder) err() error {
+	var errs []error
+	if len(d.missing) > 0 {
+		errs = append(errs, fmt.Errorf("xconfig: missing required keys: %s", strings.Join(d.missing, ", ")))
+	}
+	return errors.Join(append(errs, d.errs...)...)
+}
+
+// lookup 先找 section 中的 prefix+key  再找 [section.prefix] 中的 key
+func (d *decoder) lookup(section, prefix, key string) (string, bool) {
+	if s, ok := d.sections[section]; ok {
+		if v, ok := s.keyValue[prefix+key]; ok {
+			return v, true
+		}
+	}
+	if prefix != "" {
+		if s, ok := d.sections[section+"."+strings.TrimSuffix(prefix, ".")]; ok {
+			v, ok := s.keyValue[key]
+			return v, ok
+		}
+	}
+	return "", false
+}
+
+func (d *decoder) decodeStruct(elem reflect.Value, section, prefix string) {
+	t := elem.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := fieldName(t.Field(i))
+		if !ok {
+			continue
+		}
+		f := elem.Field(i)
+		if isSection(f.Type()) {
+			d.decodeStruct(f, section, prefix+name+".")
+			continue
+		}
+		d.decodeField(f, t.Field(i), section, prefix, name)
+	}
+}
+
+func (d *decoder) decodeField(f reflect.Value, field reflect.StructField, section, prefix, key string) {
+	value, ok := d.lookup(section, prefix, key)
+	if !ok || value == "" {
+		if def, hasDef := field.Tag.Lookup("default"); hasDef {
+			value, ok = def, true
+		} else if field.Tag.Get("required") == "true" {
+			d.missing = append(d.missing, section+"."+prefix+key)
+			return
+		}
+	}
+	if !ok {
+		return
+	}
+	if err := setValue(f, value); err != nil {
+		d.errs = append(d.errs, fmt.Errorf("xconfig: %s.%s%s: %w", section, prefix, key, err))
+	}
+}
+
+func setValue(f reflect.Value, s string) error {
+	if f.CanAddr() && f.Addr().Type().Implements(textUnmarshalerType) {
+		return f.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if f.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		if f.OverflowInt(n) {
+			return fmt.Errorf("value %s overflows %v", s, f.Type())
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		if f.OverflowUint(n) {
+			return fmt.Errorf("value %s overflows %v", s, f.Type())
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Slice:
+		items := splitList(s)
+		slice := reflect.MakeSlice(f.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		f.Set(slice)
+	case reflect.Ptr:
+		v := reflect.New(f.Type().Elem())
+		if err := setValue(v.Elem(), s); err != nil {
+			return err
+		}
+		f.Set(v)
+	default:
+		return fmt.Errorf("unsupported type %v", f.Type())
+	}
+	return nil
+}
+
+// parseBool 除了 strconv.ParseBool 支持的值  还支持 yes/no on/off
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "on", "y":
+		return true, nil
+	case "no", "off", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// splitList splits a,b,c and trims the items, empty items are dropped
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,84 @@
+package xconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testReplica struct {
+	Host string `ini:"host" required:"true"`
+	Port int    `ini:"port" default:"3307"`
+}
+
+type testDB struct {
+	Host    string        `ini:"host" required:"true"`
+	Port    int           `ini:"port" default:"3306"`
+	Timeout time.Duration `ini:"timeout" default:"5s"`
+	Debug   bool          `ini:"debug"`
+	Hosts   []string      `ini:"hosts"`
+	Weights []float64     `ini:"weights"`
+	Replica testReplica   `ini:"replica"`
+	Ignored string        `ini:"-"`
+	User    string
+}
+
+type testConfig struct {
+	Name    string `ini:"name"`
+	Project struct {
+		LogPath string `ini:"logpath"`
+		MaxSize uint   `ini:"log_max_size" default:"100"`
+	} `ini:"project"`
+	DB testDB `ini:"db"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	conf, err := LoadConfig([]byte(`{"name": "xia",
+"project": {"logpath": "/data/log/"},
+"db": {"host": "127.0.0.1", "debug": "on", "hosts": ["a", " b", "c"], "weights": [0.5, 1], "user": "root"},
+"db.replica": {"host": "127.0.0.2"}}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg testConfig
+	if err = conf.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := testConfig{Name: "xia"}
+	want.Project.LogPath = "/data/log/"
+	want.Project.MaxSize = 100
+	want.DB = testDB{
+		Host: "127.0.0.1", Port: 3306, Timeout: 5 * time.Second, Debug: true,
+		Hosts: []string{"a", "b", "c"}, Weights: []float64{0.5, 1}, User: "root",
+		Replica: testReplica{Host: "127.0.0.2", Port: 3307},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestUnmarshalSectionErrors(t *testing.T) {
+	conf, err := LoadConfig([]byte("[db]\nport = abc\ntimeout = 5\nreplica.port = 1\n"), "ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var db testDB
+	err = conf.UnmarshalSection("db", &db)
+	if err == nil {
+		t.Fatal("expect error")
+	}
+	for _, s := range []string{"missing required keys: db.host, db.replica.host", "db.port", "db.timeout"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("%q not in error %v", s, err)
+		}
+	}
+	if db.Replica.Port != 1 {
+		t.Errorf("replica.port should be read, got %d", db.Replica.Port)
+	}
+
+	if err = conf.UnmarshalSection("db", db); err == nil {
+		t.Error("expect error for non pointer")
+	}
+}
@@ -0,0 +1,160 @@
+package xconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type subscriber struct {
+	section string
+	key     string
+	fn      func(old, new string)
+}
+
+// OnChange calls fn with the old and new value when the value of key in section
+// changes on reload, a removed key has the new value "" and an added key the old value ""
+func (c *WeConfig) OnChange(section, key string, fn func(old, new string)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.subscribers = append(c.subscribers, subscriber{section: section, key: key, fn: fn})
+}
+
+// OnReload calls fn after every successful reload, eg. xlog.ApplyConfig
+func (c *WeConfig) OnReload(fn func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reloadFuncs = append(c.reloadFuncs, fn)
+}
+
+// OnError calls fn when Watch fails to reload the config,
+// the errors are written to stderr if there's no fn
+func (c *WeConfig) OnError(fn func(err error)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.errorFuncs = append(c.errorFuncs, fn)
+}
+
+// Reload loads the file again and swaps the sections, the config is kept
+// if the file can't be read or parsed
+func (c *WeConfig) Reload() error {
+	if c.path == "" {
+		return errors.New("xconfig: the config is not loaded from a file")
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	conf, err := loadData(data, c.format, c.path)
+	if err != nil {
+		return fmt.Errorf("xconfig: reload %s: %w", c.path, err)
+	}
+
+	c.lock.Lock()
+	old := c.sections
+	c.sections = conf.sections
+	c.order = conf.order
+	c.includes = conf.includes
+	c.doc = conf.doc
+	c.baseline = conf.baseline
+	subscribers := c.subscribers
+	reloadFuncs := c.reloadFuncs
+	c.lock.Unlock()
+
+	// 回调里可能会读配置  解锁之后再调用
+	for _, s := range subscribers {
+		oldValue, _ := old[s.section].get(s.key)
+		newValue, _ := conf.sections[s.section].get(s.key)
+		if oldValue != newValue {
+			s.fn(oldValue, newValue)
+		}
+	}
+	for _, fn := range reloadFuncs {
+		fn()
+	}
+	return nil
+}
+
+// Watch polls the mtime and size of the file and its includes every interval and
+// reloads the config when they change, call the returned func to stop watching
+func (c *WeConfig) Watch(interval time.Duration) (func(), error) {
+	if c.path == "" {
+		return nil, errors.New("xconfig: the config is not loaded from a file")
+	}
+	if _, err := os.Stat(c.path); err != nil {
+		return nil, err
+	}
+	last := c.snapshot()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			current := c.snapshot()
+			if _, ok := current[c.path]; !ok || current.equal(last) {
+				// 编辑器保存的时候文件可能短暂不存在  下次再检查
+				continue
+			}
+			last = current
+			if err := c.Reload(); err != nil {
+				c.reportError(err)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+type fileStat struct {
+	mtime time.Time
+	size  int64
+}
+
+type fileStats map[string]fileStat
+
+func (s fileStats) equal(other fileStats) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for name, st := range s {
+		if o, ok := other[name]; !ok || !o.mtime.Equal(st.mtime) || o.size != st.size {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshot 记录配置文件和 include 的文件的修改时间和大小  不存在的文件不记录
+func (c *WeConfig) snapshot() fileStats {
+	c.lock.Lock()
+	files := append([]string{c.path}, c.includes...)
+	c.lock.Unlock()
+	stats := make(fileStats, len(files))
+	for _, name := range files {
+		if info, err := os.Stat(name); err == nil {
+			stats[name] = fileStat{mtime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stats
+}
+
+func (c *WeConfig) reportError(err error) {
+	c.lock.Lock()
+	errorFuncs := c.errorFuncs
+	c.lock.Unlock()
+	if len(errorFuncs) == 0 {
+		fmt.Fprintf(os.Stderr, "xconfig: %v\n", err)
+		return
+	}
+	for _, fn := range errorFuncs {
+		fn(err)
+	}
+}
@@ -0,0 +1,104 @@
+package xconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloadOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.ini")
+	if err := os.WriteFile(path, []byte("[log]\nlevel = info\n[limit]\nqps = 100\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []string
+	conf.OnChange("log", "level", func(old, new string) {
+		changes = append(changes, old+"=>"+new)
+	})
+	conf.OnChange("limit", "burst", func(old, new string) {
+		changes = append(changes, old+"=>"+new)
+	})
+	conf.OnChange("limit", "qps", func(old, new string) {
+		t.Errorf("qps did not change, got %s => %s", old, new)
+	})
+	reloads := 0
+	conf.OnReload(func() { reloads++ })
+
+	if err = os.WriteFile(path, []byte("[log]\nlevel = debug\n[limit]\nqps = 100\nburst = 10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = conf.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(changes, ",") != "info=>debug,=>10" || reloads != 1 {
+		t.Errorf("got changes %v reloads %d", changes, reloads)
+	}
+
+	// 解析失败时保留之前的配置
+	if err = os.WriteFile(path, []byte("[log\nlevel = warn\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = conf.Reload(); err == nil {
+		t.Fatal("expect parse error")
+	}
+	if got := conf.GetValue("log", "level", ""); got != "debug" || reloads != 1 {
+		t.Errorf("got level %s reloads %d", got, reloads)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	if err := os.WriteFile(path, []byte(`{"log": {"level": "info"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan string, 1)
+	errs := make(chan error, 1)
+	conf.OnChange("log", "level", func(old, new string) { changed <- new })
+	conf.OnError(func(err error) { errs <- err })
+	stop, err := conf.Watch(10 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	if err = os.WriteFile(path, []byte(`{"log": {"level": "debug"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-changed:
+		if v != "debug" {
+			t.Errorf("got %s", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("change not noticed")
+	}
+
+	if err = os.WriteFile(path, []byte(`{"log": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-errs:
+		if !strings.Contains(err.Error(), "app.json") {
+			t.Errorf("got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("error not reported")
+	}
+	if got := conf.GetValue("log", "level", ""); got != "debug" {
+		t.Errorf("got %s", got)
+	}
+
+	if _, err = (&WeConfig{}).Watch(time.Second); err == nil {
+		t.Error("expect error without file")
+	}
+}
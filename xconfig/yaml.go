@@ -0,0 +1,356 @@
+package xconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yaml 只支持配置文件常用的部分：缩进的 map 和 list、- key: value 形式的 list、
+// 单双引号字符串、[a, b] {a: b} 形式的 flow 写法、| 和 > 多行字符串、# 注释
+// 不支持 anchor、tag 和多文档
+
+type yamlLine struct {
+	no     int
+	indent int
+	text   string // 去掉缩进和注释
+	raw    string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func parseYAML(data []byte) (map[string]*weSection, error) {
+	lines, err := yamlLines(string(data))
+	if err != nil {
+		return nil, err
+	}
+	p := &yamlParser{lines: lines}
+	l := p.peek()
+	if l == nil {
+		return make(map[string]*weSection), nil
+	}
+	v, err := p.parseBlock(l.indent)
+	if err != nil {
+		return nil, err
+	}
+	if l = p.peek(); l != nil {
+		return nil, fmt.Errorf("yaml: line %d: bad indentation", l.no)
+	}
+	root, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("yaml: the document must be a mapping")
+	}
+	return flatten(root), nil
+}
+
+func yamlLines(data string) ([]yamlLine, error) {
+	raws := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	lines := make([]yamlLine, 0, len(raws))
+	for i, raw := range raws {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "---" || trimmed == "..." {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if indent < len(raw) && raw[indent] == '\t' && trimmed != "" {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed for indentation", i+1)
+		}
+		text := strings.TrimSpace(yamlStripComment(raw[indent:]))
+		lines = append(lines, yamlLine{no: i + 1, indent: indent, text: text, raw: raw})
+	}
+	return lines, nil
+}
+
+// yamlStripComment 去掉引号外面的 # 注释
+func yamlStripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"':
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quote = 0
+			}
+		case quote == '\'':
+			if c == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.IndexByte(" \t[{,:", s[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#':
+			if i == 0 || s[i-1] == ' ' || s[i-1] == '\t' {
+				return s[:i]
+			}
+		}
+	}
+	return s
+}
+
+// peek returns the next line which isn't blank
+func (p *yamlParser) peek() *yamlLine {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+	if p.pos == len(p.lines) {
+		return nil
+	}
+	return &p.lines[p.pos]
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if l := p.peek(); l != nil && isYAMLListItem(l.text) {
+		return p.parseList(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for l := p.peek(); l != nil && l.indent >= indent; l = p.peek() {
+		if l.indent > indent || isYAMLListItem(l.text) {
+			return nil, fmt.Errorf("yaml: line %d: bad indentation", l.no)
+		}
+		key, rest, ok := splitYAMLKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: expect key: value", l.no)
+		}
+		no := l.no
+		p.pos++
+		if _, ok = m[key]; ok {
+			return nil, fmt.Errorf("yaml: line %d: duplicate key %s", no, key)
+		}
+		value, err := p.parseValue(indent, rest, no, true)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+func (p *yamlParser) parseList(indent int) (interface{}, error) {
+	list := make([]interface{}, 0)
+	for l := p.peek(); l != nil && l.indent >= indent; l = p.peek() {
+		if l.indent > indent || !isYAMLListItem(l.text) {
+			return nil, fmt.Errorf("yaml: line %d: bad indentation", l.no)
+		}
+		rest := strings.TrimLeft(l.text[1:], " ")
+		if _, _, ok := splitYAMLKey(rest); ok && rest[0] != '"' && rest[0] != '\'' && rest[0] != '[' && rest[0] != '{' {
+			// - key: value  把这一行当作缩进更深的 map 的第一行
+			l.indent += len(l.text) - len(rest)
+			l.text = rest
+			value, err := p.parseMap(l.indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+			continue
+		}
+		no := l.no
+		p.pos++
+		value, err := p.parseValue(indent, rest, no, false)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+// parseValue parses the value after "key:" or "-"
+func (p *yamlParser) parseValue(indent int, rest string, no int, inMap bool) (interface{}, error) {
+	if rest == "" {
+		next := p.peek()
+		// map 的值是 list 的时候 - 可以和 key 对齐
+		if next != nil && (next.indent > indent || inMap && next.indent == indent && isYAMLListItem(next.text)) {
+			return p.parseBlock(next.indent)
+		}
+		return nil, nil
+	}
+	if rest[0] == '|' || rest[0] == '>' {
+		return p.blockScalar(indent, rest), nil
+	}
+	return yamlScalar(rest, no)
+}
+
+// blockScalar reads the lines of | and > indented deeper than indent
+func (p *yamlParser) blockScalar(indent int, header string) string {
+	var lines []string
+	blockIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		l := p.lines[p.pos]
+		if strings.TrimSpace(l.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if l.indent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = l.indent
+		}
+		if l.indent < blockIndent {
+			blockIndent = l.indent
+		}
+		lines = append(lines, l.raw[blockIndent:])
+	}
+	// 结尾的空行由 chomp 控制
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	var s string
+	if header[0] == '|' {
+		s = strings.Join(lines, "\n")
+	} else {
+		var b strings.Builder
+		for i, line := range lines {
+			if i > 0 {
+				if line == "" || lines[i-1] == "" {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(' ')
+				}
+			}
+			b.WriteString(line)
+		}
+		s = b.String()
+	}
+	switch {
+	case strings.Contains(header, "-") || s == "":
+	case strings.Contains(header, "+"):
+		s += strings.Repeat("\n", trailing+1)
+	default:
+		s += "\n"
+	}
+	return s
+}
+
+// splitYAMLKey splits "key: value", the key can be quoted
+func splitYAMLKey(text string) (string, string, bool) {
+	if text == "" {
+		return "", "", false
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		rest := text[end+2:]
+		if rest != ":" && !strings.HasPrefix(rest, ": ") {
+			return "", "", false
+		}
+		return text[1 : end+1], strings.TrimSpace(rest[1:]), true
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		i = len(text) - 1
+	}
+	key := strings.TrimSpace(text[:i])
+	if key == "" || strings.ContainsAny(key[:1], "[{") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(text[i+1:]), true
+}
+
+func yamlScalar(s string, no int) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return "", nil
+	case s[0] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: invalid string %s", no, s)
+		}
+		return v, nil
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("yaml: line %d: invalid string %s", no, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s[0] == '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("yaml: line %d: missing ]", no)
+		}
+		list := make([]interface{}, 0)
+		for _, item := range splitFlow(s[1 : len(s)-1]) {
+			v, err := yamlScalar(item, no)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case s[0] == '{':
+		if s[len(s)-1] != '}' {
+			return nil, fmt.Errorf("yaml: line %d: missing }", no)
+		}
+		m := make(map[string]interface{})
+		for _, item := range splitFlow(s[1 : len(s)-1]) {
+			key, rest, ok := splitYAMLKey(item)
+			if !ok {
+				return nil, fmt.Errorf("yaml: line %d: expect key: value in %s", no, item)
+			}
+			v, err := yamlScalar(rest, no)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		}
+		return m, nil
+	case s == "~" || s == "null" || s == "Null" || s == "NULL":
+		return nil, nil
+	}
+	return s, nil
+}
+
+// splitFlow splits the items of [a, b] and {a: 1, b: 2} by the commas outside quotes and brackets
+func splitFlow(s string) []string {
+	var items []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		items = append(items, s[start:])
+	}
+	return items
+}
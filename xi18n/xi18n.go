@@ -0,0 +1,230 @@
+package xi18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ameamezhou/xiawuyue/xconfig"
+)
+
+// Bundle holds the message catalogs of all locales
+type Bundle struct {
+	defaultLocale string
+	catalogs      map[string]map[string]string // locale => key => message
+	lock          sync.RWMutex
+}
+
+// NewBundle creates a bundle, messages missing in a locale fall back to defaultLocale
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		defaultLocale: defaultLocale,
+		catalogs:      make(map[string]map[string]string),
+	}
+}
+
+// DefaultLocale returns the fallback locale
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// AddMessages adds messages to the catalog of locale, existing keys are overwritten
+func (b *Bundle) AddMessages(locale string, messages map[string]string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	catalog, ok := b.catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string)
+		b.catalogs[locale] = catalog
+	}
+	for k, v := range messages {
+		catalog[k] = v
+	}
+}
+
+// LoadConfig loads the sections named by locales from conf, eg.
+//
+//	[zh-CN]
+//	hello = 你好 %s
+//	[en]
+//	hello = hello %s
+func (b *Bundle) LoadConfig(conf *xconfig.WeConfig, locales ...string) error {
+	for _, locale := range locales {
+		messages := conf.GetSection(locale)
+		if messages == nil {
+			return fmt.Errorf("config has no section [%s]", locale)
+		}
+		b.AddMessages(locale, messages)
+	}
+	return nil
+}
+
+// LoadFile loads a json catalog of locale, nested objects are flattened with ".",
+// eg. {"user": {"name": "名字"}} => user.name
+func (b *Bundle) LoadFile(locale string, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("load i18n file %s: %w", path, err)
+	}
+	messages := make(map[string]string)
+	flatten("", raw, messages)
+	b.AddMessages(locale, messages)
+	return nil
+}
+
+// LoadDir loads every <locale>.json in dir, eg. zh-CN.json en.json
+func (b *Bundle) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		locale := strings.TrimSuffix(filepath.Base(file), ".json")
+		if err = b.LoadFile(locale, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flatten(prefix string, raw map[string]interface{}, messages map[string]string) {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch value := v.(type) {
+		case map[string]interface{}:
+			flatten(key, value, messages)
+		case string:
+			messages[key] = value
+		default:
+			messages[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// Locales returns all loaded locales
+func (b *Bundle) Locales() []string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	locales := make([]string, 0, len(b.catalogs))
+	for locale := range b.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Match returns the first loaded locale matching tags, the language is compared
+// when there is no exact match, eg. zh-TW matches zh-CN. returns "" if nothing matches
+func (b *Bundle) Match(tags ...string) string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for _, tag := range tags {
+		tag = normalize(tag)
+		if tag == "" {
+			continue
+		}
+		for locale := range b.catalogs {
+			if normalize(locale) == tag {
+				return locale
+			}
+		}
+		lang := language(tag)
+		// 语言相同的时候优先返回排序靠前的  保证结果稳定
+		matched := ""
+		for locale := range b.catalogs {
+			if language(normalize(locale)) == lang && (matched == "" || locale < matched) {
+				matched = locale
+			}
+		}
+		if matched != "" {
+			return matched
+		}
+	}
+	return ""
+}
+
+// T translates key in locale and formats it with args like fmt.Sprintf,
+// falls back to the language, the default locale and at last the key itself
+func (b *Bundle) T(locale string, key string, args ...interface{}) string {
+	msg, ok := b.lookup(locale, key)
+	if !ok {
+		if matched := b.Match(locale); matched != "" {
+			msg, ok = b.lookup(matched, key)
+		}
+	}
+	if !ok {
+		msg, ok = b.lookup(b.defaultLocale, key)
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+func (b *Bundle) lookup(locale, key string) (string, bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	msg, ok := b.catalogs[locale][key]
+	return msg, ok
+}
+
+func normalize(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+func language(tag string) string {
+	lang, _, _ := strings.Cut(tag, "-")
+	return lang
+}
+
+// ParseAcceptLanguage parses the Accept-Language header, tags are sorted by q value,
+// eg. "zh-CN,zh;q=0.9,en;q=0.8" => [zh-CN zh en]
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	items := make([]weighted, 0)
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if q <= 0 {
+			continue
+		}
+		items = append(items, weighted{tag, q})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+	tags := make([]string, len(items))
+	for i, item := range items {
+		tags[i] = item.tag
+	}
+	return tags
+}
@@ -0,0 +1,62 @@
+package xi18n
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ameamezhou/xiawuyue/xconfig"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	got := ParseAcceptLanguage("en;q=0.8, zh-CN,zh;q=0.9, fr;q=0, *;q=0.1")
+	want := []string{"zh-CN", "zh", "en"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"hello": "hello %s", "user": {"name": "name"}}`), 0644)
+	os.WriteFile(filepath.Join(dir, "i18n.ini"), []byte("[zh-CN]\nhello = 你好 %s\n"), 0644)
+
+	b := NewBundle("en")
+	if err := b.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := xconfig.LoadConfig(filepath.Join(dir, "i18n.ini"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = b.LoadConfig(conf, "zh-CN"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		locale string
+		key    string
+		want   string
+	}{
+		{"zh-CN", "hello", "你好 xia"},
+		{"zh-TW", "hello", "你好 xia"},
+		{"en", "hello", "hello xia"},
+		{"zh-CN", "user.name", "name"},
+		{"fr", "missing", "missing"},
+	}
+	for _, cs := range cases {
+		var got string
+		if cs.key == "hello" {
+			got = b.T(cs.locale, cs.key, "xia")
+		} else {
+			got = b.T(cs.locale, cs.key)
+		}
+		if got != cs.want {
+			t.Errorf("T(%s, %s) = %q, want %q", cs.locale, cs.key, got, cs.want)
+		}
+	}
+	if m := b.Match("fr", "zh"); m != "zh-CN" {
+		t.Errorf("Match got %q", m)
+	}
+}
@@ -1,10 +1,14 @@
 package xiawuyue
 
 import (
+	"fmt"
+	"html/template"
 	"net/http"
-	"path"
+	"net/netip"
+	"sort"
 	"strings"
 
+	"github.com/ameamezhou/xiawuyue/xi18n"
 	"github.com/ameamezhou/xiawuyue/xlog"
 )
 
@@ -12,13 +16,21 @@ type HandlerFunc func(c *Context)
 
 type Xia struct {
 	*RouterGroup
-	addr   string
-	router *router
-	groups []*RouterGroup
+	addr     string
+	router   *router
+	groups   []*RouterGroup
+	template *BuildTemplate // 通过 SetTemplate 绑定的模版
+	// errorHandler 处理 AbortWithError 的错误返回
+	errorHandler func(c *Context, code int, err error)
+	cmdIDs       map[int]string // RegisterHandlers 注册过的 CmdID => name
+	i18n         *xi18n.Bundle  // 通过 UseI18n 设置
+	// trustedProxies 通过 SetTrustedProxies 设置，只有这些 peer 的 X-Forwarded-For / X-Real-IP 可信
+	trustedProxies []netip.Prefix
 }
 
 type RouterGroup struct {
 	prefix      string
+	host        string        // host pattern, empty means any host
 	middlewares []HandlerFunc // support middleware
 	parent      *RouterGroup  // support nesting
 	xia         *Xia          // all groups share a Engine instance
@@ -56,6 +68,78 @@ var HandlerMap = map[string]Handler{
 }
 */
 
+// RegisterHandlers registers the Handler table as routes, the map key is used as the route name.
+// Prepare runs before Do as a guard, an error from it aborts with 403 through the error handler
+// unless Prepare already wrote the response.
+// CmdID must be unique, nothing is registered if the table is invalid
+func (x *Xia) RegisterHandlers(handlers map[string]Handler) error {
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// 先全部校验  再注册
+	cmdIDs := make(map[int]string)
+	for _, name := range names {
+		h := handlers[name]
+		if h.Do == nil || h.URL == "" {
+			return fmt.Errorf("handler %s needs Do and URL", name)
+		}
+		if _, ok := x.router.named[name]; ok {
+			return fmt.Errorf("handler %s: route name is already used", name)
+		}
+		if other, ok := x.cmdIDs[h.CmdID]; ok {
+			return fmt.Errorf("handler %s: CmdID %d is already used by %s", name, h.CmdID, other)
+		}
+		if other, ok := cmdIDs[h.CmdID]; ok {
+			return fmt.Errorf("handler %s: CmdID %d is already used by %s", name, h.CmdID, other)
+		}
+		cmdIDs[h.CmdID] = name
+	}
+
+	if x.cmdIDs == nil {
+		x.cmdIDs = make(map[int]string)
+	}
+	for _, name := range names {
+		h := handlers[name]
+		method := strings.ToUpper(h.Method)
+		if method == "" {
+			method = http.MethodGet
+		}
+		rt := x.router.addRouter(method, h.URL, h.handlerFunc())
+		rt.cmdID = h.CmdID
+		rt.Name(name)
+		x.cmdIDs[h.CmdID] = name
+	}
+	// 重新注册的路由去掉了之前的名字  之前的 CmdID 也不再占用
+	for id, name := range x.cmdIDs {
+		if rt, ok := x.router.named[name]; !ok || rt.cmdID != id {
+			delete(x.cmdIDs, id)
+		}
+	}
+	return nil
+}
+
+func (h Handler) handlerFunc() HandlerFunc {
+	return func(c *Context) {
+		if h.Prepare != nil {
+			// 记录 Prepare 有没有写过 response  写过的话不再写错误
+			rw := &responseWriter{ResponseWriter: c.Writer}
+			if err := h.Prepare(rw, c.Req); err != nil {
+				if rw.status != 0 {
+					c.Abort()
+					c.StatusCode = rw.status
+					return
+				}
+				c.AbortWithError(http.StatusForbidden, err)
+				return
+			}
+		}
+		h.Do(c.Writer, c.Req)
+	}
+}
+
 // func New() *Engine {
 // 	engine := &Engine{router: newRouter()}
 // 	engine.RouterGroup = &RouterGroup{engine: engine}
@@ -69,6 +153,7 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 	xiaWuYue := group.xia
 	newGroup := &RouterGroup{
 		prefix: group.prefix + prefix,
+		host:   group.host,
 		parent: group,
 		xia:    xiaWuYue,
 	}
@@ -80,47 +165,22 @@ func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
 	group.middlewares = append(group.middlewares, middlewares...)
 }
 
-func (group *RouterGroup) addRouter(method string, comp string, handler HandlerFunc) {
+func (group *RouterGroup) addRouter(method string, comp string, handler HandlerFunc) *Route {
 	if len(comp) > 0 && comp[0] != '/' {
 		comp = "/" + comp
 	}
 	pattern := group.prefix + comp
-	group.xia.router.addRouter(method, pattern, handler)
+	return group.xia.router.addHostRouter(group.host, method, pattern, handler)
 }
 
 // GET defines the method to add GET request
-func (group *RouterGroup) GET(pattern string, handler HandlerFunc) {
-	group.addRouter("GET", pattern, handler)
+func (group *RouterGroup) GET(pattern string, handler HandlerFunc) *Route {
+	return group.addRouter("GET", pattern, handler)
 }
 
 // POST defines the method to add POST request
-func (group *RouterGroup) POST(pattern string, handler HandlerFunc) {
-	group.addRouter("POST", pattern, handler)
-}
-
-// create static handler
-func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
-	absolutePath := path.Join(group.prefix, relativePath)
-	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
-	return func(c *Context) {
-		file := c.Param("filepath")
-		xlog.Debug(file)
-		// Check if file exists and/or if we have permission to access it
-		if _, err := fs.Open(file); err != nil {
-			c.Status(http.StatusNotFound)
-			return
-		}
-
-		fileServer.ServeHTTP(c.Writer, c.Req)
-	}
-}
-
-// serve static files
-func (group *RouterGroup) Static(relativePath string, root string) {
-	handler := group.createStaticHandler(relativePath, http.Dir(root))
-	urlPattern := path.Join(relativePath, "/*filepath")
-	// Register GET handlers
-	group.GET(urlPattern, handler)
+func (group *RouterGroup) POST(pattern string, handler HandlerFunc) *Route {
+	return group.addRouter("POST", pattern, handler)
 }
 
 // New is the constructor of xia.Xia
@@ -135,65 +195,83 @@ func New() *Xia {
 	return xiaWuYue
 }
 
-func (x *Xia) SET(method, pattern string, handler HandlerFunc) {
-	x.router.addRouter(method, pattern, handler)
+func (x *Xia) SET(method, pattern string, handler HandlerFunc) *Route {
+	return x.router.addRouter(method, pattern, handler)
 }
 
-func (x *Xia) GET(pattern string, handler HandlerFunc) {
+func (x *Xia) GET(pattern string, handler HandlerFunc) *Route {
 	// pattern = "GET-" + pattern
-	x.router.addRouter("GET", pattern, handler)
+	return x.router.addRouter("GET", pattern, handler)
 }
 
-func (x *Xia) POST(pattern string, handler HandlerFunc) {
+func (x *Xia) POST(pattern string, handler HandlerFunc) *Route {
 	// pattern = "POST-" + pattern
-	x.router.addRouter("POST", pattern, handler)
+	return x.router.addRouter("POST", pattern, handler)
 }
 
-func (x *Xia) PUT(pattern string, handler HandlerFunc) {
+func (x *Xia) PUT(pattern string, handler HandlerFunc) *Route {
 	// pattern = "PUT-" + pattern
-	x.router.addRouter("PUT", pattern, handler)
+	return x.router.addRouter("PUT", pattern, handler)
 }
 
-func (x *Xia) DELETE(pattern string, handler HandlerFunc) {
+func (x *Xia) DELETE(pattern string, handler HandlerFunc) *Route {
 	// pattern = "DELETE-" + pattern
-	x.router.addRouter("DELETE", pattern, handler)
+	return x.router.addRouter("DELETE", pattern, handler)
 }
 
-// create static handler
-func (x *Xia) createStaticHandler(absolutePath string, fs http.FileSystem) HandlerFunc {
-	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
-	return func(c *Context) {
-		file := c.Param("filepath")
-		xlog.Info(file)
-		// Check if file exists and/or if we have permission to access it
-		if _, err := fs.Open(file); err != nil {
-			c.Status(http.StatusNotFound)
-			return
-		}
-
-		fileServer.ServeHTTP(c.Writer, c.Req)
+func (x *Xia) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := newContext(w, r)
+	c.xia = x
+	c.Req.ParseForm()
+	handler, host := HandlerFunc(notFoundHandler), ""
+	if rt := x.router.find(c); rt != nil {
+		handler, host = rt.handler, rt.Host
+		c.CmdID = rt.cmdID
+		c.route = rt
 	}
-}
-
-// serve static files
-func (x *Xia) Static(absolutePath string, root string) {
-	handler := x.createStaticHandler(absolutePath, http.Dir(root))
-	urlPattern := path.Join(absolutePath, "/*filepath")
-	// Register GET handlers
-	x.GET(urlPattern, handler)
-}
 
-func (x *Xia) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var middlewares = []HandlerFunc{}
 	for _, g := range x.groups {
+		// host group 的中间件只作用于匹配到该 host 的路由
+		if g.host != "" && g.host != host {
+			continue
+		}
 		if strings.HasPrefix(r.URL.Path, g.prefix) {
 			middlewares = append(middlewares, g.middlewares...)
 		}
 	}
-	c := newContext(w, r)
-	c.middlewares = middlewares
-	c.Req.ParseForm()
-	x.router.handle(c)
+	c.middlewares = append(middlewares, handler)
+	Recovery()(c)
+}
+
+// URLFor builds the url of a named route, params are key value pairs,
+// eg. x.URLFor("user.show", "id", 12, "tab", "info") => /user/12?tab=info
+// pairs not used by the pattern are appended as query string
+func (x *Xia) URLFor(name string, params ...interface{}) (string, error) {
+	return x.router.urlFor(name, params...)
+}
+
+// SetTemplate binds the template builder to xia and registers the url func into its FuncMap,
+// so that templates can use {{url "user.show" "id" .ID}}, the t func is registered too after UseI18n
+func (x *Xia) SetTemplate(t *BuildTemplate) {
+	if t.FuncMap == nil {
+		t.FuncMap = make(template.FuncMap)
+	}
+	t.FuncMap["url"] = x.URLFor
+	if x.i18n != nil {
+		x.registerI18nFunc(t)
+	}
+	x.template = t
+}
+
+// SetErrorHandler replaces the default error handler used by AbortWithError
+func (x *Xia) SetErrorHandler(handler func(c *Context, code int, err error)) {
+	x.errorHandler = handler
+}
+
+// defaultErrorHandler responds ResponseXia in json
+func defaultErrorHandler(c *Context, code int, err error) {
+	c.JSON(code, ResponseXia{Code: code, Message: err.Error()})
 }
 
 func (x *Xia) SetAddr(addr string) {
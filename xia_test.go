@@ -0,0 +1,113 @@
+package xiawuyue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterHandlers(t *testing.T) {
+	x := New()
+	var cmdID int
+	x.Use(func(c *Context) {
+		c.NextHandle()
+		cmdID = c.CmdID
+	})
+	err := x.RegisterHandlers(map[string]Handler{
+		"page": {
+			Method: "GET",
+			Do: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "page")
+			},
+			URL:   "/wego/page",
+			CmdID: 2,
+		},
+		"admin": {
+			Method: "POST",
+			Prepare: func(w http.ResponseWriter, r *http.Request) error {
+				return errors.New("no permission")
+			},
+			Do: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "admin")
+			},
+			URL:   "/wego/admin",
+			CmdID: 3,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	x.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wego/page", nil))
+	if w.Body.String() != "page" || cmdID != 2 {
+		t.Errorf("page got %q, cmd id %d", w.Body.String(), cmdID)
+	}
+
+	w = httptest.NewRecorder()
+	x.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/wego/admin", nil))
+	var resp ResponseXia
+	if err = json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err, w.Body.String())
+	}
+	if w.Code != http.StatusForbidden || resp.Message != "no permission" || cmdID != 3 {
+		t.Errorf("admin got %d %+v, cmd id %d", w.Code, resp, cmdID)
+	}
+
+	if url, _ := x.URLFor("page"); url != "/wego/page" {
+		t.Errorf("handler route name not registered, got %q", url)
+	}
+
+	err = x.RegisterHandlers(map[string]Handler{
+		"other": {Do: func(w http.ResponseWriter, r *http.Request) {}, URL: "/other", CmdID: 2},
+	})
+	if err == nil {
+		t.Error("duplicated CmdID should fail")
+	}
+
+	// 重新注册同一个 URL  之前的名字和 CmdID 都释放掉
+	err = x.RegisterHandlers(map[string]Handler{
+		"page2": {Do: func(w http.ResponseWriter, r *http.Request) {}, URL: "/wego/page", CmdID: 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = x.URLFor("page"); err == nil {
+		t.Error("old route name is still registered")
+	}
+	if url, _ := x.URLFor("page2"); url != "/wego/page" {
+		t.Errorf("got %q", url)
+	}
+	err = x.RegisterHandlers(map[string]Handler{
+		"page": {Do: func(w http.ResponseWriter, r *http.Request) {}, URL: "/page", CmdID: 2},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRegisterHandlersPrepareWrote(t *testing.T) {
+	x := New()
+	err := x.RegisterHandlers(map[string]Handler{
+		"login": {
+			Prepare: func(w http.ResponseWriter, r *http.Request) error {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return errors.New("not logged in")
+			},
+			Do:  func(w http.ResponseWriter, r *http.Request) {},
+			URL: "/home",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	x.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/home", nil))
+	if w.Code != http.StatusFound || strings.Contains(w.Body.String(), "not logged in") {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
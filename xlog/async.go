@@ -0,0 +1,210 @@
+package xlog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed is returned when writing to a closed AsyncWriter
+var ErrClosed = errors.New("xlog: writer is closed")
+
+// OverflowPolicy decides what an AsyncWriter does when the ring buffer is full
+type OverflowPolicy int
+
+const (
+	// Block waits until the background goroutine makes room, nothing is lost
+	Block OverflowPolicy = iota
+	// Drop discards the new line and counts it, the caller never waits
+	Drop
+)
+
+// AsyncConfig configures the AsyncWriter
+type AsyncConfig struct {
+	BufferSize    int            // ring buffer 中最多缓存的行数，默认 8192
+	Policy        OverflowPolicy // 缓存满了之后的策略，默认 Block
+	FlushInterval time.Duration  // 定时把 bufio 刷到底层 writer，默认 1s
+}
+
+// AsyncWriter buffers the lines in a bounded ring buffer and writes them to the underlying
+// writer in a background goroutine, call Sync before exit to flush
+type AsyncWriter struct {
+	w    io.Writer
+	bw   *bufio.Writer
+	conf AsyncConfig
+
+	mu          sync.Mutex
+	notEmpty    *sync.Cond
+	notFull     *sync.Cond
+	ring        [][]byte
+	head, count int
+	flushDue    bool
+	closed      bool
+	syncWaiters []chan error
+
+	dropped atomic.Uint64
+	done    chan struct{}
+	stop    chan struct{}
+}
+
+// NewAsyncWriter starts the background goroutine writing to w
+func NewAsyncWriter(w io.Writer, conf AsyncConfig) *AsyncWriter {
+	if conf.BufferSize <= 0 {
+		conf.BufferSize = 8192
+	}
+	if conf.FlushInterval <= 0 {
+		conf.FlushInterval = time.Second
+	}
+	a := &AsyncWriter{
+		w:    w,
+		bw:   bufio.NewWriterSize(w, 64<<10),
+		conf: conf,
+		ring: make([][]byte, conf.BufferSize),
+		done: make(chan struct{}),
+		stop: make(chan struct{}),
+	}
+	a.notEmpty = sync.NewCond(&a.mu)
+	a.notFull = sync.NewCond(&a.mu)
+	go a.run()
+	go a.tick()
+	return a
+}
+
+// Write copies p into the ring buffer, with the Drop policy a full buffer discards p
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.count == len(a.ring) && !a.closed {
+		if a.conf.Policy == Drop {
+			a.dropped.Add(1)
+			return len(p), nil
+		}
+		a.notFull.Wait()
+	}
+	if a.closed {
+		return 0, ErrClosed
+	}
+	// 调用方会复用 p  这里需要复制一份
+	line := make([]byte, len(p))
+	copy(line, p)
+	a.ring[(a.head+a.count)%len(a.ring)] = line
+	a.count++
+	a.notEmpty.Signal()
+	return len(p), nil
+}
+
+// Dropped returns the number of lines discarded by the Drop policy
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Sync waits until all the buffered lines are written and flushed,
+// the underlying writer is synced too if it has a Sync method
+func (a *AsyncWriter) Sync() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrClosed
+	}
+	ch := make(chan error, 1)
+	a.syncWaiters = append(a.syncWaiters, ch)
+	a.flushDue = true
+	a.notEmpty.Signal()
+	a.mu.Unlock()
+	return <-ch
+}
+
+// Close flushes the buffered lines and stops the background goroutine,
+// the underlying writer is not closed
+func (a *AsyncWriter) Close() error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrClosed
+	}
+	a.closed = true
+	a.notEmpty.Broadcast()
+	a.notFull.Broadcast()
+	a.mu.Unlock()
+	close(a.stop)
+	<-a.done
+	return a.bw.Flush()
+}
+
+func (a *AsyncWriter) tick() {
+	ticker := time.NewTicker(a.conf.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			a.flushDue = true
+			a.notEmpty.Signal()
+			a.mu.Unlock()
+		}
+	}
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+	batch := make([][]byte, 0, 256)
+	for {
+		a.mu.Lock()
+		for a.count == 0 && !a.closed && !a.flushDue {
+			a.notEmpty.Wait()
+		}
+		// 一次取走全部  写的时候不占用锁
+		for a.count > 0 {
+			batch = append(batch, a.ring[a.head])
+			a.ring[a.head] = nil
+			a.head = (a.head + 1) % len(a.ring)
+			a.count--
+		}
+		flush, closed := a.flushDue, a.closed
+		waiters := a.syncWaiters
+		a.flushDue, a.syncWaiters = false, nil
+		a.notFull.Broadcast()
+		a.mu.Unlock()
+
+		var err error
+		for _, line := range batch {
+			if _, werr := a.bw.Write(line); werr != nil && err == nil {
+				err = werr
+			}
+		}
+		batch = batch[:0]
+		if flush || closed {
+			if ferr := a.bw.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
+		}
+		if len(waiters) > 0 {
+			if err == nil {
+				err = syncWriter(a.w)
+			}
+			for _, ch := range waiters {
+				ch <- err
+			}
+		}
+		if closed {
+			return
+		}
+	}
+}
+
+// syncWriter 调用 w 的 Sync 方法，stdout 和 stderr 不需要 sync，终端和管道上 sync 还会报错
+func syncWriter(w io.Writer) error {
+	if w == os.Stdout || w == os.Stderr {
+		return nil
+	}
+	if s, ok := w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
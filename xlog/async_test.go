@@ -0,0 +1,147 @@
+package xlog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter 在 release 之前阻塞写入
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriterSync(t *testing.T) {
+	buf := &blockingWriter{release: make(chan struct{})}
+	close(buf.release)
+	a := NewAsyncWriter(buf, AsyncConfig{FlushInterval: time.Hour})
+	l := NewLogger(a)
+	for i := 0; i < 100; i++ {
+		l.Infof("line %d", i)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 100 {
+		t.Errorf("got %d lines after sync", n)
+	}
+	a.Close()
+	if _, err := a.Write([]byte("x")); err != ErrClosed {
+		t.Errorf("write after close got %v", err)
+	}
+}
+
+func TestAsyncWriterDrop(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	a := NewAsyncWriter(w, AsyncConfig{BufferSize: 4, Policy: Drop})
+	// 后台协程最多取走一批  剩下的写满 ring 之后丢弃
+	for i := 0; i < 100; i++ {
+		a.Write([]byte("line\n"))
+	}
+	if a.Dropped() == 0 {
+		t.Error("expected dropped lines")
+	}
+	close(w.release)
+	a.Close()
+	if got := uint64(strings.Count(w.String(), "\n")) + a.Dropped(); got != 100 {
+		t.Errorf("written plus dropped = %d", got)
+	}
+}
+
+func TestAsyncWriterFlushInterval(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	close(w.release)
+	a := NewAsyncWriter(w, AsyncConfig{FlushInterval: 10 * time.Millisecond})
+	defer a.Close()
+	a.Write([]byte("hello\n"))
+	deadline := time.Now().Add(2 * time.Second)
+	for w.String() != "hello\n" {
+		if time.Now().After(deadline) {
+			t.Fatal("line was not flushed periodically")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func benchmarkLogger(b *testing.B, w io.Writer) {
+	l := NewLogger(w)
+	l.SetEncoder(&LogfmtEncoder{})
+	l = l.With("req_id", "0123456789")
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Infof("request done status=%d", 200)
+		}
+	})
+	b.StopTimer()
+	l.Sync()
+}
+
+func benchmarkFile(b *testing.B) *os.File {
+	f, err := os.Create(filepath.Join(b.TempDir(), "bench.log"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { f.Close() })
+	return f
+}
+
+// BenchmarkSyncFile 是原来的同步写文件
+func BenchmarkSyncFile(b *testing.B) {
+	benchmarkLogger(b, benchmarkFile(b))
+}
+
+func BenchmarkAsyncFileBlock(b *testing.B) {
+	a := NewAsyncWriter(benchmarkFile(b), AsyncConfig{})
+	defer a.Close()
+	benchmarkLogger(b, a)
+}
+
+func BenchmarkAsyncFileDrop(b *testing.B) {
+	a := NewAsyncWriter(benchmarkFile(b), AsyncConfig{Policy: Drop})
+	defer a.Close()
+	benchmarkLogger(b, a)
+}
+
+func TestFatalSyncsAsyncWriter(t *testing.T) {
+	code := -1
+	osExit = func(c int) { code = c }
+	defer func() { osExit = os.Exit }()
+
+	path := filepath.Join(t.TempDir(), "fatal.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	aw := NewAsyncWriter(f, AsyncConfig{FlushInterval: time.Hour})
+	defer aw.Close()
+	l := NewLogger(aw)
+	l.Fatalf("config %s missing", "db")
+
+	data, _ := os.ReadFile(path)
+	if code != 1 || !strings.Contains(string(data), "config db missing") {
+		t.Errorf("exit code %d, file %q", code, data)
+	}
+}
@@ -0,0 +1,174 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one log record passed to the encoders
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Caller  string // file:line
+	Message string
+	Fields  []Field
+}
+
+// Encoder encodes an entry as one line into buf, the line ends with '\n'
+type Encoder interface {
+	Encode(buf *bytes.Buffer, e *Entry)
+}
+
+// TextEncoder is the human readable format, colored if Color is true
+// eg. [info] 2006/01/02 15:04:05 [xia.go:12] listen localhost :9999 k=v
+type TextEncoder struct {
+	Color bool
+}
+
+// JSONEncoder writes one json object per line with time, level, caller, msg and the fields
+type JSONEncoder struct{}
+
+// LogfmtEncoder writes key=value pairs, eg. time=... level=info caller=xia.go:12 msg="listen localhost"
+type LogfmtEncoder struct{}
+
+// EncoderByName returns the encoder of text/json/logfmt, text is colored only on a terminal
+func EncoderByName(name string, w io.Writer) (Encoder, error) {
+	switch strings.ToLower(name) {
+	case "", "text":
+		return &TextEncoder{Color: isTerminal(w)}, nil
+	case "json":
+		return &JSONEncoder{}, nil
+	case "logfmt":
+		return &LogfmtEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", name)
+	}
+}
+
+func (enc *TextEncoder) Encode(buf *bytes.Buffer, e *Entry) {
+	color := levelColors[e.Level]
+	if enc.Color {
+		fmt.Fprintf(buf, "\033[%sm[%s]\033[0m %s \033[%sm[%s] %s", color, e.Level, e.Time.Format("2006/01/02 15:04:05"), color, e.Caller, e.Message)
+	} else {
+		fmt.Fprintf(buf, "[%s] %s [%s] %s", e.Level, e.Time.Format("2006/01/02 15:04:05"), e.Caller, e.Message)
+	}
+	for _, f := range e.Fields {
+		buf.WriteByte(' ')
+		buf.WriteString(f.Key)
+		buf.WriteByte('=')
+		buf.WriteString(formatValue(f.Value))
+	}
+	if enc.Color {
+		buf.WriteString("\033[0m")
+	}
+	buf.WriteByte('\n')
+}
+
+func (enc *JSONEncoder) Encode(buf *bytes.Buffer, e *Entry) {
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, e.Time.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(buf, e.Level.String())
+	buf.WriteString(`,"caller":`)
+	writeJSON(buf, e.Caller)
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, e.Message)
+	for _, f := range e.Fields {
+		buf.WriteByte(',')
+		writeJSON(buf, f.Key)
+		buf.WriteByte(':')
+		writeJSON(buf, fieldValue(f.Value))
+	}
+	buf.WriteString("}\n")
+}
+
+func (enc *LogfmtEncoder) Encode(buf *bytes.Buffer, e *Entry) {
+	buf.WriteString("time=")
+	buf.WriteString(e.Time.Format(time.RFC3339Nano))
+	buf.WriteString(" level=")
+	buf.WriteString(e.Level.String())
+	buf.WriteString(" caller=")
+	buf.WriteString(logfmtValue(e.Caller))
+	buf.WriteString(" msg=")
+	buf.WriteString(logfmtValue(e.Message))
+	for _, f := range e.Fields {
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(f.Key))
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(fmt.Sprint(fieldValue(f.Value))))
+	}
+	buf.WriteByte('\n')
+}
+
+// fieldValue error 和 Stringer 转成字符串  其他类型交给 json
+func fieldValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	case time.Duration:
+		return value.String()
+	}
+	return v
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// formatValue 包含空格或者引号的值用 %q 输出
+func formatValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// isTerminal 判断 w 是不是终端  设置了 NO_COLOR 环境变量的时候不输出颜色
+func isTerminal(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,57 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewLogger(buf)
+	l.SetEncoder(&JSONEncoder{})
+	l.With("req_id", "abc", "err", errors.New("bad"), "n", 3).Info("hello \"xia\"")
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err, buf.String())
+	}
+	if m["level"] != "info" || m["msg"] != `hello "xia"` || m["req_id"] != "abc" || m["err"] != "bad" || m["n"] != float64(3) {
+		t.Errorf("unexpected json %v", m)
+	}
+	if !strings.HasPrefix(m["caller"].(string), "encoder_test.go:") {
+		t.Errorf("caller %v", m["caller"])
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewLogger(buf)
+	if err := l.SetFormat("logfmt"); err != nil {
+		t.Fatal(err)
+	}
+	l.With("path", "/a b", "user id", 1).Warn("slow request")
+
+	line := buf.String()
+	for _, want := range []string{" level=warn ", ` msg="slow request"`, ` path="/a b"`, " user_id=1\n"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("%q missing %q", line, want)
+		}
+	}
+	if strings.Contains(line, "\033[") {
+		t.Error("logfmt should not contain color")
+	}
+}
+
+func TestTextEncoderNoColor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	NewLogger(buf).Error("boom")
+	if strings.Contains(buf.String(), "\033[") {
+		t.Errorf("color written to a non terminal: %q", buf.String())
+	}
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("line duplicated: %q", buf.String())
+	}
+}
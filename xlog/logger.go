@@ -0,0 +1,333 @@
+package xlog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level is the log level, entries below the minimum level of a logger are dropped
+type Level int32
+
+// log levels
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	FatalLevel
+	Disabled
+)
+
+var levelNames = []string{"debug", "info", "warn", "error", "fatal", "disabled"}
+
+// colorCode
+const (
+	colorRed     = "31"
+	colorGreen   = "32"
+	colorYellow  = "33"
+	colorBlue    = "34"
+	colorMagenta = "35"
+)
+
+var levelColors = []string{colorGreen, colorBlue, colorYellow, colorRed, colorMagenta, ""}
+
+func (l Level) String() string {
+	if l < DebugLevel || l > Disabled {
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses debug/info/warn/error/fatal/disabled, case insensitive
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "warning" {
+		return WarnLevel, nil
+	}
+	for i, name := range levelNames {
+		if name == s {
+			return Level(i), nil
+		}
+	}
+	return DebugLevel, fmt.Errorf("unknown log level %q", s)
+}
+
+// Field is a key value pair attached to the entries of a logger
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger is a leveled logger with fields, loggers created by With share the output and level
+type Logger struct {
+	out    *output
+	level  *atomic.Int32
+	module *atomic.Int32 // Named 创建的 logger 的模块 level
+	fields []Field
+}
+
+// output 多个 logger 共用的输出
+type output struct {
+	mu      sync.RWMutex
+	sinks   []Sink
+	sampler atomic.Pointer[sampler]
+	modules map[string]*atomic.Int32
+}
+
+// NewLogger creates a logger writing text to writers, colored on terminals,
+// the minimum level is DebugLevel
+func NewLogger(writers ...io.Writer) *Logger {
+	l := &Logger{
+		out:   &output{},
+		level: new(atomic.Int32),
+	}
+	l.level.Store(int32(DebugLevel))
+	l.SetOutput(writers...)
+	return l
+}
+
+// NewSinkLogger creates a logger writing to sinks, the minimum level is DebugLevel
+func NewSinkLogger(sinks ...Sink) *Logger {
+	l := NewLogger()
+	l.SetSinks(sinks...)
+	return l
+}
+
+// SetOutput replaces the outputs of the logger and all the loggers derived from it
+// with writers, the text encoder is used
+func (l *Logger) SetOutput(writers ...io.Writer) {
+	sinks := make([]Sink, 0, len(writers))
+	for _, w := range writers {
+		sinks = append(sinks, NewWriterSink(w, nil))
+	}
+	l.SetSinks(sinks...)
+}
+
+// SetSinks replaces the outputs of the logger and all the loggers derived from it
+func (l *Logger) SetSinks(sinks ...Sink) {
+	l.out.mu.Lock()
+	l.out.sinks = sinks
+	l.out.mu.Unlock()
+}
+
+// AddSink adds an output
+func (l *Logger) AddSink(sink Sink) {
+	l.out.mu.Lock()
+	l.out.sinks = append(l.out.sinks, sink)
+	l.out.mu.Unlock()
+}
+
+// AddOutput adds a writer with its own encoder
+func (l *Logger) AddOutput(w io.Writer, enc Encoder) {
+	l.AddSink(NewWriterSink(w, enc))
+}
+
+func (l *Logger) getSinks() []Sink {
+	l.out.mu.RLock()
+	defer l.out.mu.RUnlock()
+	return l.out.sinks
+}
+
+// SetEncoder sets the encoder of all the sinks which encode, eg. WriterSink
+func (l *Logger) SetEncoder(enc Encoder) {
+	for _, sink := range l.getSinks() {
+		if s, ok := sink.(interface{ SetEncoder(Encoder) }); ok {
+			s.SetEncoder(enc)
+		}
+	}
+}
+
+// SetFormat sets the encoder of all the sinks which encode by name, text/json/logfmt
+func (l *Logger) SetFormat(format string) error {
+	for _, sink := range l.getSinks() {
+		if s, ok := sink.(interface{ SetFormat(string) error }); ok {
+			if err := s.SetFormat(format); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sync flushes all the sinks, eg. AsyncWriter and files
+func (l *Logger) Sync() error {
+	if s := l.out.sampler.Load(); s != nil {
+		for _, e := range s.flush() {
+			l.write(e)
+		}
+	}
+	var err error
+	for _, sink := range l.getSinks() {
+		if serr := sink.Sync(); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// SetLevel sets the minimum level, shared with the loggers derived by With,
+// for the loggers created by Named it sets the level of the module
+func (l *Logger) SetLevel(level Level) {
+	if l.module != nil {
+		l.module.Store(int32(level))
+		return
+	}
+	l.level.Store(int32(level))
+}
+
+// GetLevel returns the minimum level
+func (l *Logger) GetLevel() Level {
+	if l.module != nil {
+		if level := l.module.Load(); level != inheritLevel {
+			return Level(level)
+		}
+	}
+	return Level(l.level.Load())
+}
+
+// Enabled reports whether entries of level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.GetLevel() && level < Disabled
+}
+
+// With returns a logger with the key value pairs added to every entry,
+// eg. logger.With("req_id", id, "uid", uid)
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]Field, len(l.fields), len(l.fields)+len(kv)/2+1)
+	copy(fields, l.fields)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			// 落单的 key 也记录下来  方便发现问题
+			fields = append(fields, Field{Key: "!BADKEY", Value: kv[i]})
+			break
+		}
+		fields = append(fields, Field{Key: fmt.Sprint(kv[i]), Value: kv[i+1]})
+	}
+	return &Logger{out: l.out, level: l.level, module: l.module, fields: fields}
+}
+
+func (l *Logger) Debug(v ...any) {
+	l.log(2, DebugLevel, fmt.Sprint(v...))
+}
+
+func (l *Logger) Debugf(format string, v ...any) {
+	l.log(2, DebugLevel, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Info(v ...any) {
+	l.log(2, InfoLevel, fmt.Sprint(v...))
+}
+
+func (l *Logger) Infof(format string, v ...any) {
+	l.log(2, InfoLevel, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Warn(v ...any) {
+	l.log(2, WarnLevel, fmt.Sprint(v...))
+}
+
+func (l *Logger) Warnf(format string, v ...any) {
+	l.log(2, WarnLevel, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Error(v ...any) {
+	l.log(2, ErrorLevel, fmt.Sprint(v...))
+}
+
+func (l *Logger) Errorf(format string, v ...any) {
+	l.log(2, ErrorLevel, fmt.Sprintf(format, v...))
+}
+
+// Fatal logs and exits the process with status 1
+func (l *Logger) Fatal(v ...any) {
+	l.log(2, FatalLevel, fmt.Sprint(v...))
+	l.exit()
+}
+
+// Fatalf logs and exits the process with status 1
+func (l *Logger) Fatalf(format string, v ...any) {
+	l.log(2, FatalLevel, fmt.Sprintf(format, v...))
+	l.exit()
+}
+
+// osExit 测试时替换
+var osExit = os.Exit
+
+// exit 退出之前先 Sync  AsyncWriter 中的日志和采样的汇总不会丢失
+func (l *Logger) exit() {
+	if err := l.Sync(); err != nil {
+		fmt.Fprintf(os.Stderr, "xlog: sync before exit failed: %v\n", err)
+	}
+	osExit(1)
+}
+
+// log 记录一条日志  skip 是到调用者的栈深度
+func (l *Logger) log(skip int, level Level, msg string) {
+	if !l.Enabled(level) {
+		return
+	}
+	// 获取调用者的文件名和行号
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		file = "???"
+		line = 0
+	} else {
+		file = file[strings.LastIndex(file, "/")+1:]
+	}
+
+	caller := file + ":" + strconv.Itoa(line)
+
+	// fatal 之后进程就退出了  不做采样
+	if s := l.out.sampler.Load(); s != nil && level < FatalLevel {
+		ok, summaries := s.check(level, caller, msg, l.fields)
+		for _, e := range summaries {
+			l.write(e)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	l.write(&Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Caller:  caller,
+		Message: msg,
+		Fields:  l.fields,
+	})
+}
+
+func (l *Logger) write(e *Entry) {
+	for _, sink := range l.getSinks() {
+		if err := sink.Write(e); err != nil {
+			fmt.Fprintf(os.Stderr, "xlog: write log failed: %v\n", err)
+		}
+	}
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 64<<10 {
+		return
+	}
+	bufferPool.Put(buf)
+}
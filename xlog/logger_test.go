@@ -0,0 +1,67 @@
+package xlog
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLoggerLevelAndFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewLogger(buf)
+	l.SetLevel(WarnLevel)
+	reqLogger := l.With("req_id", "abc", "path", "/a b")
+
+	reqLogger.Info("dropped")
+	reqLogger.Warnf("slow %d", 3)
+	l.Error("boom")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "[warn]") || !strings.Contains(lines[0], "slow 3 req_id=abc path=\"/a b\"") {
+		t.Errorf("unexpected warn line %q", lines[0])
+	}
+	if !strings.Contains(lines[0], "logger_test.go:") {
+		t.Errorf("caller missing in %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "[error]") || strings.Contains(lines[1], "req_id") {
+		t.Errorf("unexpected error line %q", lines[1])
+	}
+
+	// With 派生的 logger 共用 level
+	l.SetLevel(DebugLevel)
+	if !reqLogger.Enabled(DebugLevel) {
+		t.Error("derived logger should share the level")
+	}
+}
+
+func TestPackageLevelCaller(t *testing.T) {
+	mem := NewMemorySink(10)
+	SetSinks(mem)
+	defer std.SetOutput(os.Stdout)
+
+	_, _, line, _ := runtime.Caller(0)
+	Info("package level")
+	Errorf("package level %d", 2)
+	want := "logger_test.go:" + strconv.Itoa(line+1)
+	entries := mem.Entries()
+	if len(entries) != 2 || entries[0].Caller != want || entries[1].Caller != "logger_test.go:"+strconv.Itoa(line+2) {
+		t.Errorf("got %+v, want caller %s", entries, want)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, name := range []string{"debug", "INFO", "warning", "error", "fatal"} {
+		if _, err := ParseLevel(name); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("unknown level should fail")
+	}
+}
@@ -0,0 +1,81 @@
+package xlog
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// inheritLevel 模块没有单独设置 level 时使用 logger 的 level
+const inheritLevel = -1
+
+// Named returns a logger of the module name, entries carry the field module=name.
+// The module has its own level which can be changed by SetModuleLevel at runtime,
+// until it is set the level of l is used.
+func (l *Logger) Named(name string) *Logger {
+	fields := make([]Field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	fields = append(fields, Field{Key: "module", Value: name})
+	return &Logger{out: l.out, level: l.level, module: l.out.moduleLevel(name), fields: fields}
+}
+
+// moduleLevel 返回模块的 level，不存在时创建
+func (o *output) moduleLevel(name string) *atomic.Int32 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.modules == nil {
+		o.modules = make(map[string]*atomic.Int32)
+	}
+	level, ok := o.modules[name]
+	if !ok {
+		level = new(atomic.Int32)
+		level.Store(inheritLevel)
+		o.modules[name] = level
+	}
+	return level
+}
+
+// SetModuleLevel sets the level of the loggers created by Named(name)
+func (l *Logger) SetModuleLevel(name string, level Level) {
+	l.out.moduleLevel(name).Store(int32(level))
+}
+
+// ResetModuleLevel makes the module use the level of the logger again
+func (l *Logger) ResetModuleLevel(name string) {
+	l.out.moduleLevel(name).Store(inheritLevel)
+}
+
+// ModuleLevel returns the level set for the module, false if it's not set
+func (l *Logger) ModuleLevel(name string) (Level, bool) {
+	l.out.mu.RLock()
+	defer l.out.mu.RUnlock()
+	level, ok := l.out.modules[name]
+	if !ok || level.Load() == inheritLevel {
+		return l.GetLevel(), false
+	}
+	return Level(level.Load()), true
+}
+
+// ModuleLevels returns the modules whose level is set
+func (l *Logger) ModuleLevels() map[string]Level {
+	l.out.mu.RLock()
+	defer l.out.mu.RUnlock()
+	levels := make(map[string]Level)
+	for name, level := range l.out.modules {
+		if v := level.Load(); v != inheritLevel {
+			levels[name] = Level(v)
+		}
+	}
+	return levels
+}
+
+// Modules returns the names of all the modules, sorted
+func (l *Logger) Modules() []string {
+	l.out.mu.RLock()
+	defer l.out.mu.RUnlock()
+	names := make([]string, 0, len(l.out.modules))
+	for name := range l.out.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
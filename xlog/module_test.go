@@ -0,0 +1,65 @@
+package xlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ameamezhou/xiawuyue/xconfig"
+)
+
+func TestNamedLevel(t *testing.T) {
+	mem := NewMemorySink(10)
+	l := NewSinkLogger(mem)
+	l.SetLevel(InfoLevel)
+	db := l.Named("db")
+
+	db.Debug("hidden")
+	l.SetModuleLevel("db", DebugLevel)
+	db.With("table", "user").Debug("query")
+	l.Debug("root hidden")
+	if got := mem.Messages(); len(got) != 1 || got[0] != "query" {
+		t.Fatalf("got %q", got)
+	}
+	if f := mem.Entries()[0].Fields; len(f) != 2 || f[0].Value != "db" {
+		t.Errorf("unexpected fields %+v", f)
+	}
+
+	l.ResetModuleLevel("db")
+	if db.Enabled(DebugLevel) {
+		t.Error("module should use the logger level after reset")
+	}
+	if levels := l.ModuleLevels(); len(levels) != 0 {
+		t.Errorf("got %v", levels)
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.ini")
+	if err := os.WriteFile(path, []byte("[log]\nlevel = warn\nlevel.db = debug\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := xconfig.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewSinkLogger(NewMemorySink(10))
+	l.SetModuleLevel("cache", ErrorLevel)
+	if err = l.ApplyConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	if l.GetLevel() != WarnLevel || l.Named("db").GetLevel() != DebugLevel {
+		t.Errorf("level %v, db %v", l.GetLevel(), l.Named("db").GetLevel())
+	}
+	if _, ok := l.ModuleLevel("cache"); ok {
+		t.Error("cache is not in the config and should be reset")
+	}
+
+	if err = os.WriteFile(path, []byte("[log]\nlevel = loud\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf, _ = xconfig.LoadConfig(path)
+	if err = l.ApplyConfig(conf); err == nil {
+		t.Error("expect error for unknown level")
+	}
+}
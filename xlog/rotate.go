@@ -0,0 +1,306 @@
+package xlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 备份文件名中的时间格式  eg. app-2024-05-01T00-00-00.000.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateConfig configures the RotatingWriter
+type RotateConfig struct {
+	Filename   string        // 当前写入的日志文件，备份文件在同一个目录
+	MaxSize    int64         // 单个文件最大字节数，0 不按大小切分
+	Daily      bool          // 按本地时间每天零点切分
+	MaxAge     time.Duration // 备份保留时间，0 不按时间删除
+	MaxBackups int           // 备份最多保留个数，0 不按个数删除
+	Compress   bool          // 备份文件 gzip 压缩
+}
+
+// RotatingWriter is an io.WriteCloser which rotates the file by size and by local day,
+// rotated files are renamed to name-<time>.ext, optionally gzipped, and cleaned by MaxAge and MaxBackups
+type RotatingWriter struct {
+	conf RotateConfig
+
+	mu        sync.Mutex
+	file      *os.File
+	size      int64
+	nextDaily time.Time // 下一次按天切分的时间
+	now       func() time.Time
+
+	mill   sync.WaitGroup // 压缩和清理在后台进行
+	millMu sync.Mutex     // 多次切分的压缩和清理串行执行
+}
+
+// NewRotatingWriter opens conf.Filename for appending, the directory is created if needed
+func NewRotatingWriter(conf RotateConfig) (*RotatingWriter, error) {
+	if conf.Filename == "" {
+		return nil, fmt.Errorf("rotating writer needs a file name")
+	}
+	w := &RotatingWriter{conf: conf, now: time.Now}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.conf.Filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.conf.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	// 已有内容的文件从最后修改的那天开始算  重启之后跨天也会切分
+	start := w.now()
+	if info.Size() > 0 {
+		start = info.ModTime()
+	}
+	w.nextDaily = nextMidnight(start)
+	return nil
+}
+
+// nextMidnight 返回 t 之后的本地零点
+func nextMidnight(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.Local)
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	now := w.now()
+	if w.conf.Daily && !now.Before(w.nextDaily) {
+		// 备份文件用内容所在那天的时间命名
+		if err := w.rotate(w.nextDaily.Add(-time.Millisecond)); err != nil {
+			return 0, err
+		}
+	}
+	// 按天切分之后也要检查大小  eg. 重新打开的文件里已经有内容
+	if w.conf.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.conf.MaxSize {
+		if err := w.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file right now
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate(w.now())
+}
+
+func (w *RotatingWriter) rotate(stamp time.Time) error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+	backup := w.backupName(stamp)
+	if err := os.Rename(w.conf.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.nextDaily = nextMidnight(w.now())
+
+	w.mill.Add(1)
+	go func() {
+		defer w.mill.Done()
+		w.millRun(backup)
+	}()
+	return nil
+}
+
+// Reopen closes and reopens the file with the same name, used after logrotate moved the file
+func (w *RotatingWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	return w.open()
+}
+
+// ReopenOnSignal reopens the file when one of sigs is received, SIGHUP by default,
+// call the returned func to stop
+func (w *RotatingWriter) ReopenOnSignal(sigs ...os.Signal) func() {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := w.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "xlog: reopen %s failed: %v\n", w.conf.Filename, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// Close closes the file and waits for the background compression and cleanup
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+	w.mill.Wait()
+	return err
+}
+
+func (w *RotatingWriter) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.conf.Filename)
+	base := filepath.Base(w.conf.Filename)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func (w *RotatingWriter) backupName(stamp time.Time) string {
+	dir, prefix, ext := w.nameParts()
+	name := filepath.Join(dir, prefix+stamp.Local().Format(backupTimeFormat)+ext)
+	// 同一毫秒内切分多次的时候加序号
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = filepath.Join(dir, fmt.Sprintf("%s%s.%d%s", prefix, stamp.Local().Format(backupTimeFormat), i, ext))
+	}
+	return name
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+type backupFile struct {
+	path  string
+	stamp time.Time
+}
+
+// backups 返回全部备份文件  新的在前
+func (w *RotatingWriter) backups() ([]backupFile, error) {
+	dir, prefix, ext := w.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]backupFile, 0)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		stamp = strings.TrimPrefix(stamp, prefix)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		files = append(files, backupFile{path: filepath.Join(dir, name), stamp: t})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].stamp.After(files[j].stamp)
+	})
+	return files, nil
+}
+
+// millRun 压缩刚切分出来的文件  清理过期的备份
+func (w *RotatingWriter) millRun(backup string) {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+	if w.conf.Compress {
+		// 可能已经被之前的清理删除了
+		if err := gzipFile(backup); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "xlog: compress %s failed: %v\n", backup, err)
+		}
+	}
+	if w.conf.MaxAge <= 0 && w.conf.MaxBackups <= 0 {
+		return
+	}
+	files, err := w.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "xlog: list backups failed: %v\n", err)
+		return
+	}
+	cutoff := w.now().Add(-w.conf.MaxAge)
+	for i, f := range files {
+		if (w.conf.MaxBackups > 0 && i >= w.conf.MaxBackups) || (w.conf.MaxAge > 0 && f.stamp.Before(cutoff)) {
+			os.Remove(f.path)
+		}
+	}
+}
+
+func gzipFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
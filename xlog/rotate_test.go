@@ -0,0 +1,135 @@
+package xlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRotatingWriterSize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingWriter(RotateConfig{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    10,
+		MaxBackups: 2,
+		Compress:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	w.now = func() time.Time { return clock }
+	for i := 0; i < 4; i++ {
+		clock = clock.Add(time.Second)
+		if _, err = w.Write([]byte("123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names := listDir(t, dir)
+	// app.log 加上最新的两个压缩备份
+	if len(names) != 3 {
+		t.Fatalf("got files %v", names)
+	}
+	for _, name := range names {
+		if name != "app.log" && !strings.HasSuffix(name, ".log.gz") {
+			t.Errorf("unexpected file %s", name)
+		}
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	if string(data) != "123456789\n" {
+		t.Errorf("current file %q", data)
+	}
+}
+
+func TestRotatingWriterDaily(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingWriter(RotateConfig{Filename: filepath.Join(dir, "app.log"), Daily: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local)
+	w.now = func() time.Time { return clock }
+	w.nextDaily = nextMidnight(clock)
+	w.Write([]byte("day1\n"))
+	clock = clock.Add(2 * time.Minute)
+	w.Write([]byte("day2\n"))
+	w.Close()
+
+	names := listDir(t, dir)
+	want := "app-2024-05-01T23-59-59.999.log"
+	if len(names) != 2 || names[0] != want {
+		t.Fatalf("got files %v, want backup %s", names, want)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, want))
+	if string(data) != "day1\n" {
+		t.Errorf("backup %q", data)
+	}
+}
+
+func TestRotatingWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w, err := NewRotatingWriter(RotateConfig{Filename: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Write([]byte("before\n"))
+	// 模拟 logrotate 移走文件
+	if err = os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("after\n"))
+	data, _ := os.ReadFile(name)
+	if string(data) != "after\n" {
+		t.Errorf("reopened file %q", data)
+	}
+}
+
+func TestRotatingWriterDailyAndSize(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotatingWriter(RotateConfig{Filename: filepath.Join(dir, "app.log"), Daily: true, MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local)
+	w.now = func() time.Time { return clock }
+	w.nextDaily = nextMidnight(clock)
+	w.Write([]byte("day1\n"))
+	clock = clock.Add(2 * time.Minute)
+	w.Write([]byte("123456789\n"))
+	clock = clock.Add(time.Second)
+	w.Write([]byte("day2\n"))
+	w.Close()
+
+	// 一个按天的备份  一个按大小的备份
+	if names := listDir(t, dir); len(names) != 3 {
+		t.Fatalf("got files %v", names)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	if string(data) != "day2\n" {
+		t.Errorf("current file %q", data)
+	}
+}
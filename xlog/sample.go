@@ -0,0 +1,174 @@
+package xlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SamplingConfig limits the entries written by a hot call site, eg. an error
+// logged on every request. Fatal entries are never dropped.
+//
+// Every call site (file:line and level) writes the first Initial entries of
+// each Tick, after that 1 in Thereafter. Identical messages of the same level
+// are written once per DedupWindow. The dropped entries are reported by a
+// summary line "suppressed N similar messages" when the tick or the window
+// ends, or when the logger is synced.
+type SamplingConfig struct {
+	// Initial 每个调用点每个 Tick 内全部记录的条数，0 表示不按调用点采样
+	Initial int
+	// Thereafter 超过 Initial 之后每 Thereafter 条记录 1 条，0 表示全部丢弃
+	Thereafter int
+	// Tick 采样的周期，默认 1s
+	Tick time.Duration
+	// DedupWindow 相同的消息在窗口内只记录一次，0 表示不去重
+	DedupWindow time.Duration
+}
+
+// sampler 记录每个调用点和每条消息的计数，只在写日志的时候顺带清理过期的计数，不起 goroutine
+type sampler struct {
+	conf      SamplingConfig
+	mu        sync.Mutex
+	sites     map[string]*sampleCounter
+	dedup     map[string]*sampleCounter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type sampleCounter struct {
+	start   time.Time
+	n       int
+	dropped int
+	level   Level
+	caller  string
+	msg     string
+	fields  []Field
+}
+
+func newSampler(conf SamplingConfig) *sampler {
+	if conf.Tick <= 0 {
+		conf.Tick = time.Second
+	}
+	return &sampler{
+		conf:  conf,
+		sites: make(map[string]*sampleCounter),
+		dedup: make(map[string]*sampleCounter),
+		now:   time.Now,
+	}
+}
+
+// check reports whether the entry should be written, and returns the summaries
+// of the call sites and messages whose tick or window ended
+func (s *sampler) check(level Level, caller, msg string, fields []Field) (bool, []*Entry) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summaries := s.sweep(now)
+
+	if s.conf.DedupWindow > 0 {
+		key := level.String() + "|" + msg
+		c, ok := s.dedup[key]
+		if ok && now.Sub(c.start) < s.conf.DedupWindow {
+			c.dropped++
+			return false, summaries
+		}
+		if ok && c.dropped > 0 {
+			summaries = append(summaries, c.summary(now))
+		}
+		s.dedup[key] = &sampleCounter{start: now, level: level, caller: caller, msg: msg, fields: fields}
+	}
+
+	if s.conf.Initial > 0 {
+		key := level.String() + "|" + caller
+		c, ok := s.sites[key]
+		if !ok || now.Sub(c.start) >= s.conf.Tick {
+			if ok && c.dropped > 0 {
+				summaries = append(summaries, c.summary(now))
+			}
+			c = &sampleCounter{start: now, level: level, caller: caller, fields: fields}
+			s.sites[key] = c
+		}
+		c.n++
+		if c.n > s.conf.Initial && (s.conf.Thereafter <= 0 || (c.n-s.conf.Initial)%s.conf.Thereafter != 0) {
+			c.dropped++
+			return false, summaries
+		}
+	}
+	return true, summaries
+}
+
+// sweep 清理过期的计数，最多每个周期扫描一次
+func (s *sampler) sweep(now time.Time) []*Entry {
+	interval := s.conf.Tick
+	if s.conf.DedupWindow > 0 && (s.conf.Initial <= 0 || s.conf.DedupWindow < interval) {
+		interval = s.conf.DedupWindow
+	}
+	if now.Sub(s.lastSweep) < interval {
+		return nil
+	}
+	s.lastSweep = now
+
+	var summaries []*Entry
+	for key, c := range s.sites {
+		if now.Sub(c.start) >= s.conf.Tick {
+			if c.dropped > 0 {
+				summaries = append(summaries, c.summary(now))
+			}
+			delete(s.sites, key)
+		}
+	}
+	for key, c := range s.dedup {
+		if now.Sub(c.start) >= s.conf.DedupWindow {
+			if c.dropped > 0 {
+				summaries = append(summaries, c.summary(now))
+			}
+			delete(s.dedup, key)
+		}
+	}
+	return summaries
+}
+
+// flush returns the summaries of all the dropped entries and resets the counts
+func (s *sampler) flush() []*Entry {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var summaries []*Entry
+	for _, counters := range []map[string]*sampleCounter{s.sites, s.dedup} {
+		for _, c := range counters {
+			if c.dropped > 0 {
+				summaries = append(summaries, c.summary(now))
+				c.dropped = 0
+			}
+		}
+	}
+	return summaries
+}
+
+func (c *sampleCounter) summary(now time.Time) *Entry {
+	fields := c.fields
+	if c.msg != "" {
+		fields = make([]Field, len(c.fields), len(c.fields)+1)
+		copy(fields, c.fields)
+		fields = append(fields, Field{Key: "suppressed_msg", Value: c.msg})
+	}
+	return &Entry{
+		Time:    now,
+		Level:   c.level,
+		Caller:  c.caller,
+		Message: fmt.Sprintf("suppressed %d similar messages", c.dropped),
+		Fields:  fields,
+	}
+}
+
+// SetSampling enables sampling for the logger and all the loggers derived from it,
+// nil disables it
+func (l *Logger) SetSampling(conf *SamplingConfig) {
+	if conf == nil {
+		l.out.sampler.Store(nil)
+		return
+	}
+	l.out.sampler.Store(newSampler(*conf))
+}
@@ -0,0 +1,100 @@
+package xlog
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestSamplingLogger(conf SamplingConfig) (*Logger, *MemorySink, *time.Time) {
+	mem := NewMemorySink(100)
+	l := NewSinkLogger(mem)
+	l.SetSampling(&conf)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.out.sampler.Load().now = func() time.Time { return now }
+	return l, mem, &now
+}
+
+func TestSamplingPerCallSite(t *testing.T) {
+	l, mem, now := newTestSamplingLogger(SamplingConfig{Initial: 2, Thereafter: 3})
+	for i := 0; i < 8; i++ {
+		l.Errorf("hot %d", i)
+	}
+	// 前 2 条  之后每 3 条 1 条
+	want := []string{"hot 0", "hot 1", "hot 4", "hot 7"}
+	if got := mem.Messages(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	*now = now.Add(time.Second)
+	mem.Reset()
+	l.Errorf("hot %d", 8)
+	want = []string{"suppressed 4 similar messages", "hot 8"}
+	if got := mem.Messages(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSamplingDedup(t *testing.T) {
+	l, mem, now := newTestSamplingLogger(SamplingConfig{DedupWindow: time.Minute})
+	for i := 0; i < 5; i++ {
+		l.Warn("db down")
+	}
+	l.Warn("other")
+	if got := mem.Messages(); len(got) != 2 {
+		t.Fatalf("got %q", got)
+	}
+
+	*now = now.Add(time.Minute)
+	l.Info("tick")
+	entries := mem.Entries()
+	if len(entries) != 4 || entries[2].Message != "suppressed 4 similar messages" {
+		t.Fatalf("got %q", mem.Messages())
+	}
+	if f := entries[2].Fields; len(f) != 1 || f[0].Value != "db down" || entries[2].Level != WarnLevel {
+		t.Errorf("unexpected summary %+v", entries[2])
+	}
+}
+
+func TestSamplingSync(t *testing.T) {
+	l, mem, _ := newTestSamplingLogger(SamplingConfig{Initial: 1})
+	for i := 0; i < 3; i++ {
+		l.Error("a")
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "suppressed 2 similar messages"}
+	if got := mem.Messages(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	l.SetSampling(nil)
+	mem.Reset()
+	l.Error("a")
+	l.Error("a")
+	if got := mem.Messages(); len(got) != 2 {
+		t.Errorf("sampling should be disabled, got %q", got)
+	}
+}
+
+func TestSamplingPackageLevelCallSites(t *testing.T) {
+	mem := NewMemorySink(10)
+	SetSinks(mem)
+	SetSampling(&SamplingConfig{Initial: 1, Tick: time.Hour})
+	defer func() {
+		SetSampling(nil)
+		std.SetOutput(os.Stdout)
+	}()
+
+	for i := 0; i < 3; i++ {
+		Errorf("handler a %d", i)
+		Errorf("handler b %d", i)
+	}
+	// 两个调用点分别采样
+	want := []string{"handler a 0", "handler b 0"}
+	if got := mem.Messages(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
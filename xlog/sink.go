@@ -0,0 +1,347 @@
+package xlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink is an output of a logger, it receives every entry above the level of the logger
+type Sink interface {
+	Write(e *Entry) error
+	Sync() error
+}
+
+// WriterSink encodes the entries and writes them to an io.Writer
+type WriterSink struct {
+	mu  sync.Mutex
+	w   io.Writer
+	enc Encoder
+}
+
+// NewWriterSink creates a sink writing to w, nil enc uses the text encoder colored on terminals
+func NewWriterSink(w io.Writer, enc Encoder) *WriterSink {
+	if enc == nil {
+		enc = &TextEncoder{Color: isTerminal(w)}
+	}
+	return &WriterSink{w: w, enc: enc}
+}
+
+// StdoutSink writes to stdout
+func StdoutSink(enc Encoder) *WriterSink {
+	return NewWriterSink(os.Stdout, enc)
+}
+
+// StderrSink writes to stderr
+func StderrSink(enc Encoder) *WriterSink {
+	return NewWriterSink(os.Stderr, enc)
+}
+
+// FileSink appends to the file at path, the directory is created if needed
+func FileSink(path string, enc Encoder) (*WriterSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterSink(file, enc), nil
+}
+
+func (s *WriterSink) Write(e *Entry) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(buf, e)
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+func (s *WriterSink) Sync() error {
+	return syncWriter(s.w)
+}
+
+// Close closes the writer if it is an io.Closer
+func (s *WriterSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok && s.w != os.Stdout && s.w != os.Stderr {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *WriterSink) SetEncoder(enc Encoder) {
+	s.mu.Lock()
+	s.enc = enc
+	s.mu.Unlock()
+}
+
+func (s *WriterSink) SetFormat(format string) error {
+	enc, err := EncoderByName(format, s.w)
+	if err != nil {
+		return err
+	}
+	s.SetEncoder(enc)
+	return nil
+}
+
+// levelSink 只接收 [min, max] 之间的日志
+type levelSink struct {
+	Sink
+	min, max Level
+}
+
+// LevelSink routes the entries with min <= level <= max to sink,
+// eg. errors to stderr and the rest to a file:
+//
+//	LevelSink(StderrSink(nil), ErrorLevel, FatalLevel), LevelSink(file, DebugLevel, WarnLevel)
+func LevelSink(sink Sink, min, max Level) Sink {
+	return &levelSink{Sink: sink, min: min, max: max}
+}
+
+func (s *levelSink) Write(e *Entry) error {
+	if e.Level < s.min || e.Level > s.max {
+		return nil
+	}
+	return s.Sink.Write(e)
+}
+
+func (s *levelSink) SetEncoder(enc Encoder) {
+	if es, ok := s.Sink.(interface{ SetEncoder(Encoder) }); ok {
+		es.SetEncoder(enc)
+	}
+}
+
+func (s *levelSink) SetFormat(format string) error {
+	if fs, ok := s.Sink.(interface{ SetFormat(string) error }); ok {
+		return fs.SetFormat(format)
+	}
+	return nil
+}
+
+type multiSink []Sink
+
+// MultiSink fans the entries out to all sinks
+func MultiSink(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Write(e *Entry) error {
+	errs := make([]error, 0)
+	for _, s := range m {
+		if err := s.Write(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiSink) Sync() error {
+	errs := make([]error, 0)
+	for _, s := range m {
+		if err := s.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiSink) SetEncoder(enc Encoder) {
+	for _, s := range m {
+		if es, ok := s.(interface{ SetEncoder(Encoder) }); ok {
+			es.SetEncoder(enc)
+		}
+	}
+}
+
+func (m multiSink) SetFormat(format string) error {
+	for _, s := range m {
+		if fs, ok := s.(interface{ SetFormat(string) error }); ok {
+			if err := fs.SetFormat(format); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MemorySink keeps the last entries in memory, tests can assert against it
+type MemorySink struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewMemorySink keeps at most capacity entries, the oldest are overwritten
+func NewMemorySink(capacity int) *MemorySink {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &MemorySink{entries: make([]Entry, capacity)}
+}
+
+func (m *MemorySink) Write(e *Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[m.next] = *e
+	m.next = (m.next + 1) % len(m.entries)
+	if m.next == 0 {
+		m.full = true
+	}
+	return nil
+}
+
+func (m *MemorySink) Sync() error {
+	return nil
+}
+
+// Entries returns the kept entries, oldest first
+func (m *MemorySink) Entries() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.full {
+		return append([]Entry(nil), m.entries[:m.next]...)
+	}
+	result := make([]Entry, 0, len(m.entries))
+	result = append(result, m.entries[m.next:]...)
+	return append(result, m.entries[:m.next]...)
+}
+
+// Messages returns the messages of the kept entries, oldest first
+func (m *MemorySink) Messages() []string {
+	entries := m.Entries()
+	msgs := make([]string, len(entries))
+	for i, e := range entries {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
+
+// Reset drops all the kept entries
+func (m *MemorySink) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make([]Entry, len(m.entries))
+	m.next = 0
+	m.full = false
+}
+
+// syslog facility user
+const syslogFacilityUser = 1
+
+// syslog severity of each level
+var syslogSeverity = []int{7, 6, 4, 3, 2}
+
+// 本地 syslog socket 的位置
+var syslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogSink writes to the local syslog socket in the BSD syslog format
+type SyslogSink struct {
+	mu       sync.Mutex
+	conn     net.Conn
+	tag      string
+	hostname string
+	enc      Encoder
+}
+
+// NewSyslogSink connects to the local syslog socket, /dev/log, /var/run/syslog or /var/run/log,
+// tag defaults to the program name, nil enc writes the message and the fields in logfmt
+func NewSyslogSink(tag string, enc Encoder) (*SyslogSink, error) {
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	if enc == nil {
+		enc = &syslogEncoder{}
+	}
+	s := &SyslogSink{tag: tag, enc: enc}
+	s.hostname, _ = os.Hostname()
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SyslogSink) connect() error {
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range syslogPaths {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				s.conn = conn
+				return nil
+			}
+		}
+	}
+	return errors.New("xlog: no local syslog socket found")
+}
+
+func (s *SyslogSink) Write(e *Entry) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	severity := 7
+	if int(e.Level) < len(syslogSeverity) {
+		severity = syslogSeverity[e.Level]
+	}
+	fmt.Fprintf(buf, "<%d>%s %s %s[%d]: ", syslogFacilityUser*8+severity, e.Time.Format(time.Stamp), s.hostname, s.tag, os.Getpid())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(buf, e)
+	msg := strings.TrimSuffix(buf.String(), "\n")
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(s.conn, msg); err != nil {
+		// syslog 重启之后重新连接一次
+		s.conn.Close()
+		s.conn = nil
+		if err = s.connect(); err != nil {
+			return err
+		}
+		_, err = io.WriteString(s.conn, msg)
+		return err
+	}
+	return nil
+}
+
+func (s *SyslogSink) Sync() error {
+	return nil
+}
+
+// Close closes the syslog connection
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// syslogEncoder syslog 自己记录时间和级别  这里只写 caller 消息和字段
+type syslogEncoder struct{}
+
+func (enc *syslogEncoder) Encode(buf *bytes.Buffer, e *Entry) {
+	buf.WriteString(e.Caller)
+	buf.WriteByte(' ')
+	buf.WriteString(e.Message)
+	for _, f := range e.Fields {
+		buf.WriteByte(' ')
+		buf.WriteString(logfmtKey(f.Key))
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(fmt.Sprint(fieldValue(f.Value))))
+	}
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,78 @@
+package xlog
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLevelSinkRouting(t *testing.T) {
+	errs := NewMemorySink(10)
+	rest := NewMemorySink(10)
+	all := NewMemorySink(10)
+	l := NewSinkLogger(
+		LevelSink(errs, ErrorLevel, FatalLevel),
+		LevelSink(MultiSink(rest, all), DebugLevel, WarnLevel),
+	)
+	l.AddSink(LevelSink(all, ErrorLevel, FatalLevel))
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	if got := errs.Messages(); !reflect.DeepEqual(got, []string{"error"}) {
+		t.Errorf("error sink got %v", got)
+	}
+	if got := rest.Messages(); !reflect.DeepEqual(got, []string{"info", "warn"}) {
+		t.Errorf("rest sink got %v", got)
+	}
+	if got := all.Messages(); !reflect.DeepEqual(got, []string{"info", "warn", "error"}) {
+		t.Errorf("fan out sink got %v", got)
+	}
+}
+
+func TestMemorySinkRing(t *testing.T) {
+	m := NewMemorySink(3)
+	l := NewSinkLogger(m)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		l.Info(msg)
+	}
+	if got := m.Messages(); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Errorf("got %v", got)
+	}
+	m.Reset()
+	if len(m.Entries()) != 0 {
+		t.Error("reset should drop the entries")
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip("unixgram is not supported:", err)
+	}
+	defer conn.Close()
+	old := syslogPaths
+	syslogPaths = []string{path}
+	defer func() { syslogPaths = old }()
+
+	sink, err := NewSyslogSink("xia", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	NewSinkLogger(sink).With("uid", 7).Error("disk full")
+
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// user facility(1) * 8 + error severity(3)
+	if !strings.HasPrefix(msg, "<11>") || !strings.Contains(msg, " xia[") || !strings.HasSuffix(msg, "disk full uid=7") {
+		t.Errorf("got %q", msg)
+	}
+}
@@ -3,117 +3,250 @@ package xlog
 import (
 	"fmt"
 	"github.com/ameamezhou/xiawuyue/xconfig"
+	"io"
 	"log"
 	"os"
 	"runtime"
 	"strings"
-	"sync"
 	"time"
 )
 
-var (
-	errorLog *log.Logger
-	debugLog *log.Logger
-	infoLog  *log.Logger
-	loggers  = []*log.Logger{errorLog, debugLog}
-	mu       sync.Mutex
-)
+// std 是包级别函数使用的默认 logger
+var std = NewLogger(os.Stdout)
 
-// log levels
-const (
-	DebugLevel = iota
-	InfoLevel
-	ErrorLevel
-	Disabled
-)
+// Default returns the logger used by the package level functions
+func Default() *Logger {
+	return std
+}
 
-// colorCode
-const (
-	colorRed    = "31"
-	colorGreen  = "32"
-	colorYellow = "33"
-	colorBlue   = "34"
-)
+// SetLevel sets the minimum level of the default logger
+func SetLevel(level Level) {
+	std.SetLevel(level)
+}
+
+// With returns a logger derived from the default logger with the key value pairs
+func With(kv ...interface{}) *Logger {
+	return std.With(kv...)
+}
+
+// SetSinks replaces the outputs of the default logger
+func SetSinks(sinks ...Sink) {
+	std.SetSinks(sinks...)
+}
+
+// AddSink adds an output to the default logger
+func AddSink(sink Sink) {
+	std.AddSink(sink)
+}
+
+// Sync flushes the default logger, call it before the process exits
+func Sync() error {
+	return std.Sync()
+}
+
+// SetSampling enables sampling for the default logger, nil disables it
+func SetSampling(conf *SamplingConfig) {
+	std.SetSampling(conf)
+}
+
+// SetFormat sets the format of the default logger, text/json/logfmt
+func SetFormat(format string) error {
+	return std.SetFormat(format)
+}
 
+// InitLoger 日志写入 logFilePath，为空时写入 stdout，打开文件失败时退出进程
+// 需要自己处理错误的时候用 OpenLoger
 func InitLoger(logFilePath string) {
+	if err := OpenLoger(logFilePath); err != nil {
+		log.Fatalf("Failed to open log file: %v", err)
+	}
+}
+
+// OpenLoger 和 InitLoger 一样，打开文件失败时继续写 stdout 并返回错误
+func OpenLoger(logFilePath string) error {
 	if logFilePath == "" {
-		errorLog = log.New(os.Stdout, "\033[31m[error]\033[0m ", log.LstdFlags|log.Lshortfile)
-		debugLog = log.New(os.Stdout, "\033[32m[debug]\033[0m ", log.LstdFlags|log.Lshortfile)
-		infoLog = log.New(os.Stdout, "\033[34m[info]\033[0m ", log.LstdFlags|log.Lshortfile)
-	} else {
-		logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			log.Fatalf("Failed to open log file: %v", err)
-		}
-		errorLog = log.New(logFile, "\033[31m[error]\033[0m ", log.LstdFlags|log.Lshortfile)
-		debugLog = log.New(logFile, "\033[32m[debug]\033[0m ", log.LstdFlags|log.Lshortfile)
-		infoLog = log.New(logFile, "\033[34m[info]\033[0m ", log.LstdFlags|log.Lshortfile)
+		std.SetOutput(os.Stdout)
+		return nil
+	}
+	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
 	}
+	std.SetOutput(logFile)
+	return nil
 }
 
+// InitLogerProject 按照 [project] 配置写入 logpath/project.log，本地时间每天零点切分，
+// 也可以按大小切分，收到 SIGHUP 的时候重新打开文件，方便配合 logrotate
+//
+//	[project]
+//	project = xia
+//	logpath = /data/log/
+//	log_max_size = 100      # MB
+//	log_max_age = 7         # 天
+//	log_max_backups = 30
+//	log_compress = true
+//	log_async = true        # 异步写入，进程退出前调用 xlog.Sync()
+//
+// 打开文件失败时退出进程  需要自己处理错误的时候用 OpenLogerProject
 func InitLogerProject(conf *xconfig.WeConfig) {
+	if err := OpenLogerProject(conf); err != nil {
+		log.Fatalf("Failed to open log file: %v", err)
+	}
+}
+
+// OpenLogerProject 和 InitLogerProject 一样，打开文件失败时返回错误
+func OpenLogerProject(conf *xconfig.WeConfig) error {
 	logPath := conf.GetValue("project", "logpath", "")
 	projname := conf.GetValue("project", "project", "")
-	logFile := fmt.Sprintf("%s%s_%s", logPath, projname, time.Now().Format("2006-01-02"))
-	InitLoger(logFile)
-	go func() {
-		for {
-			now := time.Now()
-			nextMidnight := now.Add(24 * time.Hour)
-			midnightTime := nextMidnight.Truncate(24 * time.Hour)
-
-			duration := midnightTime.Sub(now)
-			if duration < 0 {
-				duration += 24 * time.Hour
-			}
-
-			time.Sleep(duration)
-
-			currentDate := time.Now().Format("2006-01-02")
-			newLogFilePath := fmt.Sprintf("%s%s_%s", logPath, projname, currentDate)
-
-			logFile, err := os.OpenFile(newLogFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-			if err != nil {
-				log.Fatalf("Failed to open new log file: %v", err)
-			}
-
-			errorLog.SetOutput(logFile)
-			debugLog.SetOutput(logFile)
-			infoLog.SetOutput(logFile)
-
-			Infof("日志文件已切换到: %s\n", newLogFilePath)
+	w, err := NewRotatingWriter(RotateConfig{
+		Filename:   fmt.Sprintf("%s%s.log", logPath, projname),
+		MaxSize:    int64(conf.GetValueInt("project", "log_max_size", 0)) << 20,
+		Daily:      true,
+		MaxAge:     time.Duration(conf.GetValueInt("project", "log_max_age", 0)) * 24 * time.Hour,
+		MaxBackups: conf.GetValueInt("project", "log_max_backups", 0),
+		Compress:   conf.GetValue("project", "log_compress", "false") == "true",
+	})
+	if err != nil {
+		return err
+	}
+	w.ReopenOnSignal()
+	if conf.GetValue("project", "log_async", "false") == "true" {
+		std.SetOutput(NewAsyncWriter(w, AsyncConfig{}))
+		return nil
+	}
+	std.SetOutput(w)
+	return nil
+}
+
+// ApplyConfig applies the [log] section of conf to the default logger, see Logger.ApplyConfig
+func ApplyConfig(conf *xconfig.WeConfig) error {
+	return std.ApplyConfig(conf)
+}
+
+// ApplyConfig applies the [log] section of conf, it can be called again when the config reloads,
+// modules removed from the section use the level of the logger again
+//
+//	[log]
+//	level = info
+//	format = json
+//	level.db = debug     # Named("db") 的 level
+func (l *Logger) ApplyConfig(conf *xconfig.WeConfig) error {
+	section := conf.GetSection("log")
+	if section == nil {
+		return nil
+	}
+	if s, ok := section["level"]; ok {
+		level, err := ParseLevel(s)
+		if err != nil {
+			return fmt.Errorf("xlog: [log] level: %w", err)
+		}
+		l.level.Store(int32(level))
+	}
+	if format, ok := section["format"]; ok {
+		if err := l.SetFormat(format); err != nil {
+			return fmt.Errorf("xlog: [log] format: %w", err)
 		}
-	}()
+	}
+
+	modules := make(map[string]Level)
+	for key, s := range section {
+		name, ok := strings.CutPrefix(key, "level.")
+		if !ok || name == "" {
+			continue
+		}
+		level, err := ParseLevel(s)
+		if err != nil {
+			return fmt.Errorf("xlog: [log] %s: %w", key, err)
+		}
+		modules[name] = level
+	}
+	for _, name := range l.Modules() {
+		if _, ok := modules[name]; !ok {
+			l.ResetModuleLevel(name)
+		}
+	}
+	for name, level := range modules {
+		l.SetModuleLevel(name, level)
+	}
+	return nil
+}
+
+// WatchConfig applies the [log] section of conf to the default logger now and after
+// every reload of conf, eg. conf.Watch(time.Second)
+func WatchConfig(conf *xconfig.WeConfig) error {
+	conf.OnReload(func() {
+		if err := std.ApplyConfig(conf); err != nil {
+			std.Errorf("apply log config failed: %v", err)
+		}
+	})
+	return std.ApplyConfig(conf)
+}
+
+// Named returns a logger of the module name derived from the default logger
+func Named(name string) *Logger {
+	return std.Named(name)
+}
+
+// SetModuleLevel sets the level of the module of the default logger
+func SetModuleLevel(name string, level Level) {
+	std.SetModuleLevel(name, level)
+}
+
+// GetLevel returns the level of the default logger
+func GetLevel() Level {
+	return std.GetLevel()
 }
 
 // 文件写入后续优化可以改为 mmap 写入  不用 write file  效率会更高
+// 包级别的函数直接调用 std.log  和 Logger 的方法一样 skip 是 2
 
 func Error(v ...any) {
-	logWithPosition(errorLog, fmt.Sprint(v...), colorRed)
+	std.log(2, ErrorLevel, fmt.Sprint(v...))
 }
 
 func Errorf(format string, v ...any) {
-	logWithPosition(errorLog, fmt.Sprintf(format, v...), colorRed)
+	std.log(2, ErrorLevel, fmt.Sprintf(format, v...))
+}
+
+func Warn(v ...any) {
+	std.log(2, WarnLevel, fmt.Sprint(v...))
+}
+
+func Warnf(format string, v ...any) {
+	std.log(2, WarnLevel, fmt.Sprintf(format, v...))
 }
 
 func Debug(v ...any) {
-	logWithPosition(debugLog, fmt.Sprint(v...), colorGreen)
+	std.log(2, DebugLevel, fmt.Sprint(v...))
 }
 
 func Debugf(format string, v ...any) {
-	logWithPosition(debugLog, fmt.Sprintf(format, v...), colorGreen)
+	std.log(2, DebugLevel, fmt.Sprintf(format, v...))
 }
 
 func Info(v ...any) {
-	logWithPosition(infoLog, fmt.Sprint(v...), colorBlue)
+	std.log(2, InfoLevel, fmt.Sprint(v...))
 }
 
 func Infof(format string, v ...any) {
-	logWithPosition(infoLog, fmt.Sprintf(format, v...), colorBlue)
+	std.log(2, InfoLevel, fmt.Sprintf(format, v...))
+}
+
+// Fatal logs with the default logger and exits the process with status 1
+func Fatal(v ...any) {
+	std.log(2, FatalLevel, fmt.Sprint(v...))
+	std.exit()
+}
+
+// Fatalf logs with the default logger and exits the process with status 1
+func Fatalf(format string, v ...any) {
+	std.log(2, FatalLevel, fmt.Sprintf(format, v...))
+	std.exit()
 }
 
 func TestColor(v ...any) {
-	logWithPosition(debugLog, fmt.Sprint(v...), "33")
+	logWithPosition(nil, fmt.Sprint(v...), colorYellow)
 }
 
 // logWithPosition 记录一条带有文件名和行号的日志信息
@@ -130,11 +263,19 @@ func logWithPosition(l *log.Logger, msg, colorCode string) {
 		file = file[strings.LastIndex(file, "/")+1:]
 	}
 
-	// 格式化日志信息
-	message := fmt.Sprintf("\033[%sm [%s:%d] %s \033[0m", colorCode, file, line, msg)
-	fmt.Println(message)
+	// 格式化日志信息  只有写到终端的时候才加颜色
+	var w io.Writer = os.Stdout
+	if l != nil {
+		w = l.Writer()
+	}
+	message := fmt.Sprintf("[%s:%d] %s", file, line, msg)
+	if isTerminal(w) {
+		message = fmt.Sprintf("\033[%sm %s \033[0m", colorCode, message)
+	}
 	if l != nil {
 		l.Println(message)
+	} else {
+		fmt.Println(message)
 	}
 }
 